@@ -1,11 +1,139 @@
 package dto
 
+// Kind는 sink가 값을 해석하는 방식을 나타낸다.
+type Kind int
+
+const (
+	// KindUntyped는 NewItem으로 생성된 아이템으로 Write 시점에 값이 0으로 초기화된다.
+	KindUntyped Kind = iota
+	// KindCounter는 누적 카운터로 Write 이후에도 값을 유지한다.
+	KindCounter
+	// KindGauge는 마지막으로 설정된 값을 유지한다.
+	KindGauge
+	// KindDeltaCounter는 Write 시점에 0으로 초기화되는 주기별 카운터이다.
+	KindDeltaCounter
+	// KindHistogram은 Histogram 필드에 값을 기록한다.
+	KindHistogram
+	// KindSummary는 Summary 필드에 주기별 분위수를 기록한다.
+	KindSummary
+	// KindAvg는 Aggregation 필드에 주기별 평균, 최소, 최대, 개수, 합계를 기록한다.
+	KindAvg
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindCounter:
+		return "counter"
+	case KindGauge:
+		return "gauge"
+	case KindDeltaCounter:
+		return "delta_counter"
+	case KindHistogram:
+		return "histogram"
+	case KindSummary:
+		return "summary"
+	case KindAvg:
+		return "avg"
+	default:
+		return "untyped"
+	}
+}
+
+// ValueType은 Metric의 값이 기록된 필드를 나타낸다.
+type ValueType int
+
+const (
+	// ValueFloat은 Value 필드를 사용한다.
+	ValueFloat ValueType = iota
+	// ValueInt은 IntValue 필드를 사용한다.
+	ValueInt
+	// ValueUint은 UintValue 필드를 사용한다.
+	ValueUint
+	// ValueBool은 BoolValue 필드를 사용한다.
+	ValueBool
+	// ValueString은 StringValue 필드를 사용한다.
+	ValueString
+)
+
 type Metric struct {
 	Category    string   `json:"category"`
 	SubCategory string   `json:"sub_category"`
 	ItemName    string   `json:"item_name"`
 	Description string   `json:"description"`
+	Kind        Kind     `json:"kind"`
 	TagNames    []string `json:"tag_names"`
 	TagValues   []string `json:"tag_values"`
 	Value       float64  `json:"value"`
+
+	// ConstraintTags는 TagNames, TagValues의 앞쪽에 위치한 ConstraintTags의 개수이다.
+	ConstraintTags int `json:"constraint_tags,omitempty"`
+
+	ValueType   ValueType `json:"value_type,omitempty"`
+	IntValue    int64     `json:"int_value,omitempty"`
+	UintValue   uint64    `json:"uint_value,omitempty"`
+	BoolValue   bool      `json:"bool_value,omitempty"`
+	StringValue string    `json:"string_value,omitempty"`
+
+	Histogram *Histogram `json:"histogram,omitempty"`
+	Summary   *Summary   `json:"summary,omitempty"`
+
+	Aggregation *Aggregation `json:"aggregation,omitempty"`
+
+	// Fields가 비어있지 않으면 Value 대신 이름이 있는 여러 값을 기록한다.
+	Fields []Field `json:"fields,omitempty"`
+}
+
+// TypedValue는 ValueType에 맞는 값을 float64, int64, uint64, bool, string 중 하나로 반환한다.
+func (m *Metric) TypedValue() interface{} {
+	switch m.ValueType {
+	case ValueInt:
+		return m.IntValue
+	case ValueUint:
+		return m.UintValue
+	case ValueBool:
+		return m.BoolValue
+	case ValueString:
+		return m.StringValue
+	default:
+		return m.Value
+	}
+}
+
+type Field struct {
+	Name  string  `json:"name"`
+	Value float64 `json:"value"`
+}
+
+// Bucket은 UpperBound 이하로 관측된 누적 개수를 나타낸다.
+type Bucket struct {
+	UpperBound float64 `json:"upper_bound"`
+	Count      uint64  `json:"count"`
+}
+
+// Histogram의 Buckets는 UpperBound 오름차순이며 +Inf 버킷은 포함하지 않는다. (+Inf 버킷의 개수는 Count와 같다.)
+type Histogram struct {
+	Count   uint64   `json:"count"`
+	Sum     float64  `json:"sum"`
+	Buckets []Bucket `json:"buckets"`
+}
+
+type Quantile struct {
+	Quantile float64 `json:"quantile"`
+	Value    float64 `json:"value"`
+}
+
+// Summary의 Quantiles는 해당 주기에 관측 값이 없으면 비어있다.
+type Summary struct {
+	Count     uint64     `json:"count"`
+	Sum       float64    `json:"sum"`
+	Quantiles []Quantile `json:"quantiles"`
+}
+
+// Aggregation의 Min, Max, Mean은 Count가 0이면 0이다.
+type Aggregation struct {
+	Count uint64  `json:"count"`
+	Sum   float64 `json:"sum"`
+	Min   float64 `json:"min"`
+	Max   float64 `json:"max"`
+	Mean  float64 `json:"mean"`
 }
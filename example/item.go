@@ -3,7 +3,6 @@ package main
 import (
 	"fmt"
 	"runtime"
-	"time"
 
 	"github.com/shirou/gopsutil/v3/cpu"
 	"github.com/shirou/gopsutil/v3/disk"
@@ -11,8 +10,9 @@ import (
 	"github.com/winey-dev/telemetry/metric"
 )
 
+// 모든 값은 Registry.Gather() 시점에 callback을 호출하여 수집된다.
 var (
-	MemoryUsage = metric.NewItem(metric.ItemOpts{
+	MemoryUsage = metric.NewGaugeFunc(metric.GaugeOpts{
 		Category:    "system",
 		SubCategory: "resource",
 		ItemName:    "memory_usage",
@@ -21,8 +21,8 @@ var (
 			TagNames:  []string{"env", "version"},
 			TagValues: []string{"production", "v1.0"},
 		},
-	})
-	CPUUsage = metric.NewItem(metric.ItemOpts{
+	}, getMemoryUsage)
+	CPUUsage = metric.NewGaugeFunc(metric.GaugeOpts{
 		Category:    "system",
 		SubCategory: "resource",
 		ItemName:    "cpu_usage",
@@ -31,8 +31,8 @@ var (
 			TagNames:  []string{"env", "version"},
 			TagValues: []string{"production", "v1.0"},
 		},
-	})
-	DiskUsage = metric.NewItemVec(metric.ItemOpts{
+	}, getCPUUsage)
+	DiskUsage = metric.NewGaugeFuncVec(metric.GaugeOpts{
 		Category:    "system",
 		SubCategory: "disk",
 		ItemName:    "disk_usage",
@@ -41,9 +41,9 @@ var (
 			TagNames:  []string{"env", "version"},
 			TagValues: []string{"production", "v1.0"},
 		},
-	}, "disk_path")
+	}, "disk_path", getDiskUsage)
 
-	NetworkTx = metric.NewItemVec(metric.ItemOpts{
+	NetworkTx = metric.NewCounterFuncVec(metric.CounterOpts{
 		Category:    "system",
 		SubCategory: "network",
 		ItemName:    "transmitted_traffic",
@@ -52,8 +52,8 @@ var (
 			TagNames:  []string{"env", "version"},
 			TagValues: []string{"production", "v1.0"},
 		},
-	}, "interface")
-	NetworkRx = metric.NewItemVec(metric.ItemOpts{
+	}, "interface", func() map[string]float64 { return getNetworkUsage(true) })
+	NetworkRx = metric.NewCounterFuncVec(metric.CounterOpts{
 		Category:    "system",
 		SubCategory: "network",
 		ItemName:    "received_traffic",
@@ -62,59 +62,54 @@ var (
 			TagNames:  []string{"env", "version"},
 			TagValues: []string{"production", "v1.0"},
 		},
-	}, "interface")
+	}, "interface", func() map[string]float64 { return getNetworkUsage(false) })
 )
 
-func getMemoryUsage() {
+func getMemoryUsage() float64 {
 	var m runtime.MemStats
 	runtime.ReadMemStats(&m)
 	// m.Alloc is bytes allocated and still in use
-	//fmt.Printf("Alloc = %v MiB\n", m.Alloc)
-	MemoryUsage.Add(float64(m.Alloc))
+	return float64(m.Alloc)
 }
 
-func getCPUUsage() {
-	percentages, _ := cpu.Percent(0, false)
-	//fmt.Printf("CPU Usage: %v%%\n", percentages[0])
-	CPUUsage.Add(percentages[0])
-
+func getCPUUsage() float64 {
+	percentages, err := cpu.Percent(0, false)
+	if err != nil || len(percentages) == 0 {
+		return 0
+	}
+	return percentages[0]
 }
 
-func getDiskUsage() {
+func getDiskUsage() map[string]float64 {
 	partitions, err := disk.Partitions(false)
 	if err != nil {
 		fmt.Println("Error getting disk partitions:", err)
-		return
+		return nil
 	}
+	usages := make(map[string]float64, len(partitions))
 	for _, p := range partitions {
 		usageStat, err := disk.Usage(p.Mountpoint)
 		if err != nil {
 			continue
 		}
-		//fmt.Printf("Disk Usage for %s: %v%%\n", p.Mountpoint, usageStat.Used)
-		DiskUsage.WithTagValues(p.Mountpoint).Add(float64(usageStat.Used))
+		usages[p.Mountpoint] = float64(usageStat.Used)
 	}
+	return usages
 }
 
-func getNetworkUsage() {
+func getNetworkUsage(sent bool) map[string]float64 {
 	ioCounters, err := net.IOCounters(true)
 	if err != nil {
 		fmt.Println("Error getting network IO counters:", err)
-		return
+		return nil
 	}
+	usages := make(map[string]float64, len(ioCounters))
 	for _, io := range ioCounters {
-		//fmt.Printf("Network Interface: %s, Bytes Sent: %d, Bytes Received: %d\n", io.Name, io.BytesSent, io.BytesRecv)
-		NetworkTx.WithTagValues(io.Name).Add(float64(io.BytesSent))
-		NetworkRx.WithTagValues(io.Name).Add(float64(io.BytesRecv))
-	}
-}
-func collect() {
-	ticker := time.NewTicker(2 * time.Second)
-	defer ticker.Stop()
-	for range ticker.C {
-		getMemoryUsage()
-		getCPUUsage()
-		getDiskUsage()
-		getNetworkUsage()
+		if sent {
+			usages[io.Name] = float64(io.BytesSent)
+		} else {
+			usages[io.Name] = float64(io.BytesRecv)
+		}
 	}
+	return usages
 }
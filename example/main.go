@@ -1,6 +1,10 @@
 package main
 
 import (
+	"context"
+	"os"
+	"os/signal"
+
 	"github.com/winey-dev/telemetry/register/influxdb"
 )
 
@@ -21,5 +25,7 @@ func main() {
 	register.Start()
 	defer register.Stop()
 
-	collect()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	<-ctx.Done()
 }
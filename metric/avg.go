@@ -0,0 +1,185 @@
+package metric
+
+import (
+	"math"
+	"sync"
+
+	"github.com/winey-dev/telemetry/dto"
+)
+
+// AvgItem은 수집 주기 동안 관측한 값의 평균, 최소, 최대, 개수, 합계를 함께 기록한다.
+// 다섯 값은 하나의 잠금 안에서 읽고 초기화되기 때문에 항상 같은 시점의 값이다.
+type AvgItem interface {
+	Metric
+	Collector
+
+	Observe(float64)
+}
+
+func NewAvgItem(opts ItemOpts) AvgItem {
+	if opts.Category == "" || opts.SubCategory == "" || opts.ItemName == "" {
+		panic(ErrRequiredFields.Error())
+	}
+	if !opts.ConstraintTags.IsEmpty() && !opts.ConstraintTags.IsValid() {
+		panic(ErrInvalidTagValues.Error())
+	}
+	desc := NewDesc(opts.Category, opts.SubCategory, opts.ItemName, opts.Description, opts.ConstraintTags)
+	return newAvgItem(desc, nil)
+}
+
+func newAvgItem(desc *Desc, tagValues []string) *avgItem {
+	result := &avgItem{desc: desc, tagValues: tagValues}
+	result.reset()
+	result.init(result)
+	return result
+}
+
+type avgItem struct {
+	mtx   sync.Mutex
+	count uint64
+	sum   float64
+	min   float64
+	max   float64
+
+	selfCollector
+	desc      *Desc
+	tagValues []string
+	err       error
+}
+
+// reset은 mtx를 잡은 상태에서 호출해야 한다.
+func (a *avgItem) reset() {
+	a.count = 0
+	a.sum = 0
+	a.min = math.Inf(1)
+	a.max = math.Inf(-1)
+}
+
+func (a *avgItem) Desc() *Desc {
+	return a.desc
+}
+
+func (a *avgItem) Observe(value float64) {
+	if a.err != nil || math.IsNaN(value) {
+		return
+	}
+	a.touch()
+	a.mtx.Lock()
+	a.count++
+	a.sum += value
+	if value < a.min {
+		a.min = value
+	}
+	if value > a.max {
+		a.max = value
+	}
+	a.mtx.Unlock()
+}
+
+func (a *avgItem) Write(out *dto.Metric) error {
+	if a.err != nil {
+		return a.err
+	}
+
+	// Write 호출 시점에 값을 읽고 초기화 시킨다.
+	a.mtx.Lock()
+	aggregation := a.aggregation()
+	a.reset()
+	a.mtx.Unlock()
+
+	a.write(out, aggregation)
+	return nil
+}
+
+// implement Reader interface
+func (a *avgItem) Read(out *dto.Metric) error {
+	if a.err != nil {
+		return a.err
+	}
+	a.mtx.Lock()
+	aggregation := a.aggregation()
+	a.mtx.Unlock()
+
+	a.write(out, aggregation)
+	return nil
+}
+
+// aggregation은 mtx를 잡은 상태에서 호출해야 한다.
+func (a *avgItem) aggregation() *dto.Aggregation {
+	if a.count == 0 {
+		return &dto.Aggregation{}
+	}
+	return &dto.Aggregation{
+		Count: a.count,
+		Sum:   a.sum,
+		Min:   a.min,
+		Max:   a.max,
+		Mean:  a.sum / float64(a.count),
+	}
+}
+
+func (a *avgItem) write(out *dto.Metric, aggregation *dto.Aggregation) {
+	writeDesc(out, a.desc, a.tagValues)
+	out.Kind = dto.KindAvg
+	out.Value = aggregation.Mean
+	out.Aggregation = aggregation
+}
+
+type AvgItemVec struct {
+	*MetricVec
+}
+
+func NewAvgItemVec(opts ItemOpts, tagNames ...string) *AvgItemVec {
+	if len(tagNames) == 0 {
+		panic("tagNames must not be empty")
+	}
+	desc := NewDesc(opts.Category, opts.SubCategory, opts.ItemName, opts.Description, opts.ConstraintTags, tagNames...)
+	return &AvgItemVec{
+		MetricVec: NewMetricVec(desc, func(tagValues ...string) Metric {
+			if len(tagValues) != len(desc.TagNames) {
+				panic("tagValues length does not match tagNames length")
+			}
+			return newAvgItem(desc, tagValues)
+		}),
+	}
+}
+
+func (v *AvgItemVec) WithTagValues(tagValues ...string) AvgItem {
+	metric, err := v.GetMetricWithTagValues(tagValues...)
+	if err != nil {
+		return &avgItem{err: err}
+	}
+	return metric
+}
+
+func (v *AvgItemVec) GetMetricWithTagValues(tagValues ...string) (AvgItem, error) {
+	metric, err := v.MetricVec.WithTagValues(tagValues...)
+	if err != nil {
+		return nil, err
+	}
+	return metric.(AvgItem), nil
+}
+
+func (v *AvgItemVec) With(tags map[string]string) AvgItem {
+	metric, err := v.GetMetricWith(tags)
+	if err != nil {
+		return &avgItem{err: err}
+	}
+	return metric
+}
+
+func (v *AvgItemVec) GetMetricWith(tags map[string]string) (AvgItem, error) {
+	metric, err := v.MetricVec.GetMetricWith(tags)
+	if err != nil {
+		return nil, err
+	}
+	return metric.(AvgItem), nil
+}
+
+func (v *AvgItemVec) CurryWith(tags map[string]string) (*AvgItemVec, error) {
+	vec, err := v.MetricVec.CurryWith(tags)
+	if err != nil {
+		return nil, err
+	}
+	return &AvgItemVec{MetricVec: vec}, nil
+}
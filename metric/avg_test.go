@@ -0,0 +1,46 @@
+package metric
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/winey-dev/telemetry/dto"
+)
+
+func TestAvgItemResetsAfterCollect(t *testing.T) {
+	vec := NewAvgItemVec(ItemOpts{Category: "c", SubCategory: "s", ItemName: "i"}, "t")
+	avg := vec.WithTagValues("a")
+	for _, v := range []float64{2, 8, 5, math.NaN()} {
+		avg.Observe(v)
+	}
+
+	// Read는 값을 초기화하지 않는다.
+	var read dto.Metric
+	if err := avg.(Reader).Read(&read); err != nil {
+		t.Fatal(err)
+	}
+	want := &dto.Aggregation{Count: 3, Sum: 15, Min: 2, Max: 8, Mean: 5}
+	if !reflect.DeepEqual(read.Aggregation, want) {
+		t.Fatalf("read aggregation %+v, want %+v", read.Aggregation, want)
+	}
+
+	got := collectOne(t, vec)
+	if got.Kind != dto.KindAvg || got.Value != 5 {
+		t.Fatalf("kind %s, value %v, want avg 5", got.Kind, got.Value)
+	}
+	if !reflect.DeepEqual(got.Aggregation, want) {
+		t.Fatalf("aggregation %+v, want %+v", got.Aggregation, want)
+	}
+
+	// 관측 값이 없는 주기는 Count가 0이고 min, max는 이전 주기의 값이 남지 않는다.
+	if got := collectOne(t, vec); !reflect.DeepEqual(got.Aggregation, &dto.Aggregation{}) {
+		t.Fatalf("empty interval aggregation %+v, want zero", got.Aggregation)
+	}
+
+	avg.Observe(-1)
+	want = &dto.Aggregation{Count: 1, Sum: -1, Min: -1, Max: -1, Mean: -1}
+	if got := collectOne(t, vec); !reflect.DeepEqual(got.Aggregation, want) {
+		t.Fatalf("next interval aggregation %+v, want %+v", got.Aggregation, want)
+	}
+}
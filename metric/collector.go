@@ -7,11 +7,24 @@ type Collector interface {
 
 type selfCollector struct {
 	self Metric
+	// activity는 Vec에 속한 series의 사용 기록이다. Vec에 속하지 않으면 nil이다.
+	activity *activity
 }
 
 func (c *selfCollector) init(m Metric) {
 	c.self = m
 }
+
+// setActivity는 Vec이 series를 생성할 때 호출한다.
+func (c *selfCollector) setActivity(a *activity) {
+	c.activity = a
+}
+
+// touch는 값을 기록하는 메서드에서 호출하여 series가 사용 중임을 기록한다.
+func (c *selfCollector) touch() {
+	c.activity.touch()
+}
+
 func (c *selfCollector) Describe(ch chan<- *Desc) {
 	ch <- c.self.Desc()
 }
@@ -0,0 +1,172 @@
+package metric
+
+import "github.com/winey-dev/telemetry/dto"
+
+// Counter는 누적 카운터로 Write 이후에도 값이 유지된다.
+type Counter interface {
+	Metric
+	Collector
+
+	Inc()
+	Add(float64)
+}
+
+// DeltaCounter는 주기별 카운터로 Write 시점에 값이 0으로 초기화된다.
+type DeltaCounter interface {
+	Metric
+	Collector
+
+	Inc()
+	Add(float64)
+}
+
+type CounterOpts Opts
+type DeltaCounterOpts Opts
+
+func NewCounter(opts CounterOpts) Counter {
+	return newCounter(newItem(Opts(opts), dto.KindCounter))
+}
+
+func NewDeltaCounter(opts DeltaCounterOpts) DeltaCounter {
+	return newCounter(newItem(Opts(opts), dto.KindDeltaCounter))
+}
+
+// counter는 item을 감싸 값을 증가시키는 메서드만 노출한다.
+// item을 embed하면 type assertion으로 Set, Sub를 호출하여 값을 감소시킬 수 있으므로 필드로 둔다.
+type counter struct {
+	selfCollector
+	item *item
+}
+
+func newCounter(i *item) *counter {
+	result := &counter{item: i}
+	result.init(result)
+	return result
+}
+
+func (c *counter) Desc() *Desc { return c.item.Desc() }
+
+func (c *counter) Write(out *dto.Metric) error { return c.item.Write(out) }
+
+func (c *counter) Read(out *dto.Metric) error { return c.item.Read(out) }
+
+func (c *counter) IsError() bool { return c.item.IsError() }
+
+func (c *counter) Error() error { return c.item.Error() }
+
+// Add는 value가 음수이면 panic이 발생한다.
+func (c *counter) Add(value float64) {
+	if value < 0 {
+		panic(ErrCounterDecrease.Error())
+	}
+	c.touch()
+	c.item.Add(value)
+}
+
+func (c *counter) Inc() {
+	c.Add(1)
+}
+
+type CounterVec struct {
+	*MetricVec
+}
+
+func NewCounterVec(opts CounterOpts, tagNames ...string) *CounterVec {
+	return &CounterVec{
+		MetricVec: newItemMetricVec(Opts(opts), dto.KindCounter, wrapCounter, tagNames...),
+	}
+}
+
+func (v *CounterVec) WithTagValues(tagValues ...string) Counter {
+	metric, err := v.GetMetricWithTagValues(tagValues...)
+	if err != nil {
+		return newCounter(&item{err: err})
+	}
+	return metric
+}
+
+func (v *CounterVec) GetMetricWithTagValues(tagValues ...string) (Counter, error) {
+	metric, err := v.MetricVec.WithTagValues(tagValues...)
+	if err != nil {
+		return nil, err
+	}
+	return metric.(Counter), nil
+}
+
+func (v *CounterVec) With(tags map[string]string) Counter {
+	metric, err := v.GetMetricWith(tags)
+	if err != nil {
+		return newCounter(&item{err: err})
+	}
+	return metric
+}
+
+func (v *CounterVec) GetMetricWith(tags map[string]string) (Counter, error) {
+	metric, err := v.MetricVec.GetMetricWith(tags)
+	if err != nil {
+		return nil, err
+	}
+	return metric.(Counter), nil
+}
+
+func (v *CounterVec) CurryWith(tags map[string]string) (*CounterVec, error) {
+	vec, err := v.MetricVec.CurryWith(tags)
+	if err != nil {
+		return nil, err
+	}
+	return &CounterVec{MetricVec: vec}, nil
+}
+
+type DeltaCounterVec struct {
+	*MetricVec
+}
+
+func NewDeltaCounterVec(opts DeltaCounterOpts, tagNames ...string) *DeltaCounterVec {
+	return &DeltaCounterVec{
+		MetricVec: newItemMetricVec(Opts(opts), dto.KindDeltaCounter, wrapCounter, tagNames...),
+	}
+}
+
+func (v *DeltaCounterVec) WithTagValues(tagValues ...string) DeltaCounter {
+	metric, err := v.GetMetricWithTagValues(tagValues...)
+	if err != nil {
+		return newCounter(&item{err: err})
+	}
+	return metric
+}
+
+func (v *DeltaCounterVec) GetMetricWithTagValues(tagValues ...string) (DeltaCounter, error) {
+	metric, err := v.MetricVec.WithTagValues(tagValues...)
+	if err != nil {
+		return nil, err
+	}
+	return metric.(DeltaCounter), nil
+}
+
+func (v *DeltaCounterVec) With(tags map[string]string) DeltaCounter {
+	metric, err := v.GetMetricWith(tags)
+	if err != nil {
+		return newCounter(&item{err: err})
+	}
+	return metric
+}
+
+func (v *DeltaCounterVec) GetMetricWith(tags map[string]string) (DeltaCounter, error) {
+	metric, err := v.MetricVec.GetMetricWith(tags)
+	if err != nil {
+		return nil, err
+	}
+	return metric.(DeltaCounter), nil
+}
+
+func (v *DeltaCounterVec) CurryWith(tags map[string]string) (*DeltaCounterVec, error) {
+	vec, err := v.MetricVec.CurryWith(tags)
+	if err != nil {
+		return nil, err
+	}
+	return &DeltaCounterVec{MetricVec: vec}, nil
+}
+
+func wrapCounter(i *item) Metric {
+	return newCounter(i)
+}
@@ -0,0 +1,52 @@
+package metric
+
+import (
+	"testing"
+
+	"github.com/winey-dev/telemetry/dto"
+)
+
+func TestCounterPanicsOnNegativeAdd(t *testing.T) {
+	counters := map[string]interface{ Add(float64) }{
+		"counter":           NewCounter(CounterOpts{Category: "c", SubCategory: "s", ItemName: "i"}),
+		"delta counter":     NewDeltaCounter(DeltaCounterOpts{Category: "c", SubCategory: "s", ItemName: "i"}),
+		"counter vec child": NewCounterVec(CounterOpts{Category: "c", SubCategory: "s", ItemName: "i"}, "t").WithTagValues("a"),
+	}
+	for name, c := range counters {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if r := recover(); r != ErrCounterDecrease.Error() {
+					t.Fatalf("recovered %v, want %q", r, ErrCounterDecrease.Error())
+				}
+			}()
+			c.Add(-1)
+		})
+	}
+}
+
+func TestCounterKeepsValueAcrossCollect(t *testing.T) {
+	c := NewCounter(CounterOpts{Category: "c", SubCategory: "s", ItemName: "i"})
+	c.Add(2)
+	c.Inc()
+	for i := 0; i < 2; i++ {
+		if got := collectOne(t, c); got.Kind != dto.KindCounter || got.Value != 3 {
+			t.Fatalf("collect %d: kind %s, value %v, want counter 3", i, got.Kind, got.Value)
+		}
+	}
+}
+
+func TestDeltaCounterResetsAfterCollect(t *testing.T) {
+	c := NewDeltaCounterVec(DeltaCounterOpts{Category: "c", SubCategory: "s", ItemName: "i"}, "t")
+	c.WithTagValues("a").Add(5)
+
+	if got := collectOne(t, c); got.Kind != dto.KindDeltaCounter || got.Value != 5 {
+		t.Fatalf("first collect: kind %s, value %v, want delta_counter 5", got.Kind, got.Value)
+	}
+	if got := collectOne(t, c); got.Value != 0 {
+		t.Fatalf("second collect: value %v, want 0", got.Value)
+	}
+	c.WithTagValues("a").Inc()
+	if got := collectOne(t, c); got.Value != 1 {
+		t.Fatalf("third collect: value %v, want 1", got.Value)
+	}
+}
@@ -3,7 +3,13 @@ package metric
 import "errors"
 
 var (
-	ErrInvalidTagValues = errors.New("invalid tag values")
-	ErrRequiredTagNames = errors.New("required tag names are missing")
-	ErrRequiredFields   = errors.New("required fields are missing in the item options")
+	ErrInvalidTagValues   = errors.New("invalid tag values")
+	ErrRequiredTagNames   = errors.New("required tag names are missing")
+	ErrRequiredFields     = errors.New("required fields are missing in the item options")
+	ErrCounterDecrease    = errors.New("counter cannot decrease in value")
+	ErrInvalidBuckets     = errors.New("buckets must be in strictly increasing order")
+	ErrInvalidObjectives  = errors.New("objectives must be between 0 and 1")
+	ErrDuplicateFieldName = errors.New("duplicate field name")
+	ErrUnknownTagName     = errors.New("unknown tag name")
+	ErrCurriedTagName     = errors.New("tag is already curried")
 )
@@ -0,0 +1,192 @@
+package metric
+
+import (
+	"math"
+	"sync/atomic"
+
+	"github.com/winey-dev/telemetry/dto"
+)
+
+// FieldsItem은 하나의 Desc 아래에 이름이 있는 여러 값을 기록한다.
+// InfluxDB에서는 하나의 point에 여러 field로 기록된다.
+// 값은 Write 이후에도 유지된다(gauge).
+type FieldsItem interface {
+	Metric
+	Collector
+
+	Set(field string, value float64)
+	Add(field string, value float64)
+	SetFields(values map[string]float64)
+}
+
+type FieldsItemOpts struct {
+	Category       string
+	SubCategory    string
+	ItemName       string
+	Description    string
+	ConstraintTags ConstraintTags
+
+	// FieldNames는 기록할 field 이름 목록이다. 목록에 없는 field의 값은 무시된다.
+	FieldNames []string
+}
+
+func NewFieldsItem(opts FieldsItemOpts) FieldsItem {
+	if opts.Category == "" || opts.SubCategory == "" || opts.ItemName == "" || len(opts.FieldNames) == 0 {
+		panic(ErrRequiredFields.Error())
+	}
+	if !opts.ConstraintTags.IsEmpty() && !opts.ConstraintTags.IsValid() {
+		panic(ErrInvalidTagValues.Error())
+	}
+	desc := NewDesc(opts.Category, opts.SubCategory, opts.ItemName, opts.Description, opts.ConstraintTags)
+	return newFieldsItem(desc, fieldIndexes(opts.FieldNames), opts.FieldNames, nil)
+}
+
+func fieldIndexes(fieldNames []string) map[string]int {
+	indexes := make(map[string]int, len(fieldNames))
+	for i, name := range fieldNames {
+		if name == "" {
+			panic(ErrRequiredFields.Error())
+		}
+		if _, ok := indexes[name]; ok {
+			panic(ErrDuplicateFieldName.Error())
+		}
+		indexes[name] = i
+	}
+	return indexes
+}
+
+func newFieldsItem(desc *Desc, indexes map[string]int, fieldNames, tagValues []string) *fieldsItem {
+	result := &fieldsItem{
+		valBits:    make([]uint64, len(fieldNames)),
+		desc:       desc,
+		fieldNames: fieldNames,
+		indexes:    indexes,
+		tagValues:  tagValues,
+	}
+	result.init(result)
+	return result
+}
+
+type fieldsItem struct {
+	valBits []uint64
+
+	selfCollector
+	desc       *Desc
+	fieldNames []string
+	indexes    map[string]int
+	tagValues  []string
+	err        error
+}
+
+func (f *fieldsItem) Desc() *Desc {
+	return f.desc
+}
+
+func (f *fieldsItem) Set(field string, value float64) {
+	if i, ok := f.indexes[field]; ok {
+		f.touch()
+		atomic.StoreUint64(&f.valBits[i], math.Float64bits(value))
+	}
+}
+
+func (f *fieldsItem) Add(field string, value float64) {
+	i, ok := f.indexes[field]
+	if !ok {
+		return
+	}
+	f.touch()
+	for {
+		oldBits := atomic.LoadUint64(&f.valBits[i])
+		newBits := math.Float64bits(math.Float64frombits(oldBits) + value)
+		if atomic.CompareAndSwapUint64(&f.valBits[i], oldBits, newBits) {
+			return
+		}
+	}
+}
+
+func (f *fieldsItem) SetFields(values map[string]float64) {
+	for field, value := range values {
+		f.Set(field, value)
+	}
+}
+
+func (f *fieldsItem) Write(out *dto.Metric) error {
+	return f.Read(out)
+}
+
+// implement Reader interface
+func (f *fieldsItem) Read(out *dto.Metric) error {
+	if f.err != nil {
+		return f.err
+	}
+	writeDesc(out, f.desc, f.tagValues)
+	out.Kind = dto.KindGauge
+	out.Fields = make([]dto.Field, len(f.fieldNames))
+	for i, name := range f.fieldNames {
+		out.Fields[i] = dto.Field{Name: name, Value: math.Float64frombits(atomic.LoadUint64(&f.valBits[i]))}
+	}
+	return nil
+}
+
+type FieldsItemVec struct {
+	*MetricVec
+}
+
+func NewFieldsItemVec(opts FieldsItemOpts, tagNames ...string) *FieldsItemVec {
+	if len(tagNames) == 0 {
+		panic("tagNames must not be empty")
+	}
+	if len(opts.FieldNames) == 0 {
+		panic(ErrRequiredFields.Error())
+	}
+	indexes := fieldIndexes(opts.FieldNames)
+	desc := NewDesc(opts.Category, opts.SubCategory, opts.ItemName, opts.Description, opts.ConstraintTags, tagNames...)
+	return &FieldsItemVec{
+		MetricVec: NewMetricVec(desc, func(tagValues ...string) Metric {
+			if len(tagValues) != len(desc.TagNames) {
+				panic("tagValues length does not match tagNames length")
+			}
+			return newFieldsItem(desc, indexes, opts.FieldNames, tagValues)
+		}),
+	}
+}
+
+func (v *FieldsItemVec) WithTagValues(tagValues ...string) FieldsItem {
+	metric, err := v.GetMetricWithTagValues(tagValues...)
+	if err != nil {
+		return &fieldsItem{err: err}
+	}
+	return metric
+}
+
+func (v *FieldsItemVec) GetMetricWithTagValues(tagValues ...string) (FieldsItem, error) {
+	metric, err := v.MetricVec.WithTagValues(tagValues...)
+	if err != nil {
+		return nil, err
+	}
+	return metric.(FieldsItem), nil
+}
+
+func (v *FieldsItemVec) With(tags map[string]string) FieldsItem {
+	metric, err := v.GetMetricWith(tags)
+	if err != nil {
+		return &fieldsItem{err: err}
+	}
+	return metric
+}
+
+func (v *FieldsItemVec) GetMetricWith(tags map[string]string) (FieldsItem, error) {
+	metric, err := v.MetricVec.GetMetricWith(tags)
+	if err != nil {
+		return nil, err
+	}
+	return metric.(FieldsItem), nil
+}
+
+func (v *FieldsItemVec) CurryWith(tags map[string]string) (*FieldsItemVec, error) {
+	vec, err := v.MetricVec.CurryWith(tags)
+	if err != nil {
+		return nil, err
+	}
+	return &FieldsItemVec{MetricVec: vec}, nil
+}
@@ -0,0 +1,54 @@
+package metric
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/winey-dev/telemetry/dto"
+)
+
+func TestFieldsItemKeepsFieldOrder(t *testing.T) {
+	vec := NewFieldsItemVec(FieldsItemOpts{
+		Category:    "c",
+		SubCategory: "s",
+		ItemName:    "i",
+		FieldNames:  []string{"rx", "tx", "errors", "drops"},
+	}, "t")
+	item := vec.WithTagValues("a")
+	item.SetFields(map[string]float64{"drops": 4, "errors": 3, "tx": 2, "rx": 1, "unknown": 9})
+	item.Add("rx", 10)
+	item.Add("unknown", 1)
+
+	want := []dto.Field{{Name: "rx", Value: 11}, {Name: "tx", Value: 2}, {Name: "errors", Value: 3}, {Name: "drops", Value: 4}}
+	// map 순회 순서와 관계없이 FieldNames 순서를 유지하고 Write 이후에도 값이 유지된다.
+	for i := 0; i < 3; i++ {
+		got := collectOne(t, vec)
+		if got.Kind != dto.KindGauge {
+			t.Fatalf("kind %s, want gauge", got.Kind)
+		}
+		if !reflect.DeepEqual(got.Fields, want) {
+			t.Fatalf("collect %d: fields %+v, want %+v", i, got.Fields, want)
+		}
+	}
+}
+
+func TestFieldIndexesRejectsInvalidNames(t *testing.T) {
+	tests := []struct {
+		name       string
+		fieldNames []string
+		want       error
+	}{
+		{"empty", []string{"rx", ""}, ErrRequiredFields},
+		{"duplicate", []string{"rx", "tx", "rx"}, ErrDuplicateFieldName},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if r := recover(); r != tt.want.Error() {
+					t.Fatalf("recovered %v, want %q", r, tt.want.Error())
+				}
+			}()
+			fieldIndexes(tt.fieldNames)
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package metric
+
+import (
+	"sort"
+
+	"github.com/winey-dev/telemetry/dto"
+)
+
+// GaugeFunc는 Collect 시점(Registry.Gather)에 함수를 호출하여 값을 기록한다.
+type GaugeFunc interface {
+	Metric
+	Collector
+}
+
+// CounterFunc는 Collect 시점에 함수를 호출하여 누적 카운터 값을 기록한다.
+// 함수는 단조 증가하는 값을 반환해야 한다.
+type CounterFunc interface {
+	Metric
+	Collector
+}
+
+func NewGaugeFunc(opts GaugeOpts, fn func() float64) GaugeFunc {
+	return newValueFunc(Opts(opts), dto.KindGauge, fn)
+}
+
+func NewCounterFunc(opts CounterOpts, fn func() float64) CounterFunc {
+	return newValueFunc(Opts(opts), dto.KindCounter, fn)
+}
+
+func newValueFunc(opts Opts, kind dto.Kind, fn func() float64) *valueFunc {
+	if opts.Category == "" || opts.SubCategory == "" || opts.ItemName == "" || fn == nil {
+		panic(ErrRequiredFields.Error())
+	}
+	if !opts.ConstraintTags.IsEmpty() && !opts.ConstraintTags.IsValid() {
+		panic(ErrInvalidTagValues.Error())
+	}
+	desc := NewDesc(opts.Category, opts.SubCategory, opts.ItemName, opts.Description, opts.ConstraintTags)
+	return &valueFunc{desc: desc, kind: kind, fn: fn}
+}
+
+type valueFunc struct {
+	desc *Desc
+	kind dto.Kind
+	fn   func() float64
+}
+
+func (v *valueFunc) Desc() *Desc {
+	return v.desc
+}
+
+func (v *valueFunc) Describe(ch chan<- *Desc) {
+	ch <- v.desc
+}
+
+// Collect는 fn을 호출한 값을 constMetric으로 보낸다.
+// Gather의 deadline 안에서 값을 읽도록 Write가 아닌 Collect 시점에 호출한다.
+func (v *valueFunc) Collect(ch chan<- Metric) {
+	ch <- &constMetric{desc: v.desc, kind: v.kind, value: v.fn()}
+}
+
+func (v *valueFunc) Write(out *dto.Metric) error {
+	writeDesc(out, v.desc, nil)
+	out.Kind = v.kind
+	out.Value = v.fn()
+	return nil
+}
+
+// implement Reader interface
+func (v *valueFunc) Read(out *dto.Metric) error {
+	return v.Write(out)
+}
+
+// FuncVec은 Collect 시점에 함수를 한 번 호출하고, 반환된 map의 key를 태그 값으로 하는 metric을 생성한다.
+type FuncVec struct {
+	desc *Desc
+	kind dto.Kind
+	fn   func() map[string]float64
+}
+
+// NewGaugeFuncVec은 fn이 반환한 map의 key를 tagName의 값으로 사용하는 gauge를 생성한다.
+func NewGaugeFuncVec(opts GaugeOpts, tagName string, fn func() map[string]float64) *FuncVec {
+	return newFuncVec(Opts(opts), dto.KindGauge, tagName, fn)
+}
+
+// NewCounterFuncVec은 fn이 반환한 map의 key를 tagName의 값으로 사용하는 누적 카운터를 생성한다.
+func NewCounterFuncVec(opts CounterOpts, tagName string, fn func() map[string]float64) *FuncVec {
+	return newFuncVec(Opts(opts), dto.KindCounter, tagName, fn)
+}
+
+func newFuncVec(opts Opts, kind dto.Kind, tagName string, fn func() map[string]float64) *FuncVec {
+	if tagName == "" {
+		panic("tagName must not be empty")
+	}
+	if opts.Category == "" || opts.SubCategory == "" || opts.ItemName == "" || fn == nil {
+		panic(ErrRequiredFields.Error())
+	}
+	if !opts.ConstraintTags.IsEmpty() && !opts.ConstraintTags.IsValid() {
+		panic(ErrInvalidTagValues.Error())
+	}
+	return &FuncVec{
+		desc: NewDesc(opts.Category, opts.SubCategory, opts.ItemName, opts.Description, opts.ConstraintTags, tagName),
+		kind: kind,
+		fn:   fn,
+	}
+}
+
+func (v *FuncVec) Describe(ch chan<- *Desc) {
+	ch <- v.desc
+}
+
+func (v *FuncVec) Collect(ch chan<- Metric) {
+	values := v.fn()
+	tagValues := make([]string, 0, len(values))
+	for tagValue := range values {
+		tagValues = append(tagValues, tagValue)
+	}
+	sort.Strings(tagValues)
+	for _, tagValue := range tagValues {
+		ch <- &constMetric{desc: v.desc, kind: v.kind, tagValues: []string{tagValue}, value: values[tagValue]}
+	}
+}
+
+// constMetric은 Collect 시점에 계산된 값을 그대로 기록한다.
+type constMetric struct {
+	desc      *Desc
+	kind      dto.Kind
+	tagValues []string
+	value     float64
+}
+
+func (c *constMetric) Desc() *Desc {
+	return c.desc
+}
+
+func (c *constMetric) Write(out *dto.Metric) error {
+	writeDesc(out, c.desc, c.tagValues)
+	out.Kind = c.kind
+	out.Value = c.value
+	return nil
+}
+
+// implement Reader interface
+func (c *constMetric) Read(out *dto.Metric) error {
+	return c.Write(out)
+}
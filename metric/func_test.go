@@ -0,0 +1,72 @@
+package metric
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/winey-dev/telemetry/dto"
+)
+
+func TestFuncSampledAtCollect(t *testing.T) {
+	var calls int
+	g := NewGaugeFunc(GaugeOpts{Category: "c", SubCategory: "s", ItemName: "i"}, func() float64 {
+		calls++
+		return float64(calls * 10)
+	})
+	if calls != 0 {
+		t.Fatalf("fn called %d times before Collect", calls)
+	}
+	for i := 1; i <= 2; i++ {
+		got := collectOne(t, g)
+		if got.Kind != dto.KindGauge || got.Value != float64(i*10) {
+			t.Fatalf("collect %d: kind %s, value %v, want gauge %d", i, got.Kind, got.Value, i*10)
+		}
+	}
+	if calls != 2 {
+		t.Fatalf("fn called %d times, want 2", calls)
+	}
+}
+
+func TestFuncVecSampledAtCollect(t *testing.T) {
+	values := map[string]float64{"b": 2, "a": 1}
+	vec := NewCounterFuncVec(CounterOpts{Category: "c", SubCategory: "s", ItemName: "i"}, "t", func() map[string]float64 {
+		return values
+	})
+
+	got := collect(t, vec)
+	if len(got) != 2 || got[0].TagValues[0] != "a" || got[1].TagValues[0] != "b" {
+		t.Fatalf("collected %+v, want a, b in order", got)
+	}
+	if got[0].Kind != dto.KindCounter || got[0].Value != 1 || got[1].Value != 2 {
+		t.Fatalf("collected %+v", got)
+	}
+
+	// 사라진 key는 다음 Collect에 포함되지 않는다.
+	values = map[string]float64{"c": 3}
+	got = collect(t, vec)
+	if len(got) != 1 || !reflect.DeepEqual(got[0].TagValues, []string{"c"}) || got[0].Value != 3 {
+		t.Fatalf("collected %+v, want only c=3", got)
+	}
+}
+
+func TestNewFuncVecRequiresName(t *testing.T) {
+	fn := func() map[string]float64 { return nil }
+	tests := []struct {
+		name string
+		opts GaugeOpts
+	}{
+		{"category", GaugeOpts{SubCategory: "s", ItemName: "i"}},
+		{"sub category", GaugeOpts{Category: "c", ItemName: "i"}},
+		{"item name", GaugeOpts{Category: "c", SubCategory: "s"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if r := recover(); r != ErrRequiredFields.Error() {
+					t.Fatalf("recovered %v, want %q", r, ErrRequiredFields.Error())
+				}
+			}()
+			NewGaugeFuncVec(tt.opts, "t", fn)
+		})
+	}
+}
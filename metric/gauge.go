@@ -0,0 +1,71 @@
+package metric
+
+import "github.com/winey-dev/telemetry/dto"
+
+// Gauge는 마지막으로 설정된 값을 유지한다. Write 시점에 값이 초기화되지 않는다.
+type Gauge interface {
+	Metric
+	Collector
+
+	Set(float64)
+	Inc()
+	Dec()
+	Add(float64)
+	Sub(float64)
+}
+
+type GaugeOpts Opts
+
+func NewGauge(opts GaugeOpts) Gauge {
+	return newItem(Opts(opts), dto.KindGauge)
+}
+
+type GaugeVec struct {
+	*MetricVec
+}
+
+func NewGaugeVec(opts GaugeOpts, tagNames ...string) *GaugeVec {
+	return &GaugeVec{
+		MetricVec: newItemMetricVec(Opts(opts), dto.KindGauge, func(i *item) Metric { return i }, tagNames...),
+	}
+}
+
+func (v *GaugeVec) WithTagValues(tagValues ...string) Gauge {
+	metric, err := v.GetMetricWithTagValues(tagValues...)
+	if err != nil {
+		return &item{err: err}
+	}
+	return metric
+}
+
+func (v *GaugeVec) GetMetricWithTagValues(tagValues ...string) (Gauge, error) {
+	metric, err := v.MetricVec.WithTagValues(tagValues...)
+	if err != nil {
+		return nil, err
+	}
+	return metric.(Gauge), nil
+}
+
+func (v *GaugeVec) With(tags map[string]string) Gauge {
+	metric, err := v.GetMetricWith(tags)
+	if err != nil {
+		return &item{err: err}
+	}
+	return metric
+}
+
+func (v *GaugeVec) GetMetricWith(tags map[string]string) (Gauge, error) {
+	metric, err := v.MetricVec.GetMetricWith(tags)
+	if err != nil {
+		return nil, err
+	}
+	return metric.(Gauge), nil
+}
+
+func (v *GaugeVec) CurryWith(tags map[string]string) (*GaugeVec, error) {
+	vec, err := v.MetricVec.CurryWith(tags)
+	if err != nil {
+		return nil, err
+	}
+	return &GaugeVec{MetricVec: vec}, nil
+}
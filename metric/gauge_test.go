@@ -0,0 +1,50 @@
+package metric
+
+import (
+	"testing"
+
+	"github.com/winey-dev/telemetry/dto"
+)
+
+func TestGaugeKeepsValueAcrossCollect(t *testing.T) {
+	g := NewGaugeVec(GaugeOpts{Category: "c", SubCategory: "s", ItemName: "i"}, "t")
+	child := g.WithTagValues("a")
+	child.Set(10)
+	child.Inc()
+	child.Sub(3)
+
+	for i := 0; i < 2; i++ {
+		got := collectOne(t, g)
+		if got.Kind != dto.KindGauge || got.Value != 8 {
+			t.Fatalf("collect %d: kind %s, value %v, want gauge 8", i, got.Kind, got.Value)
+		}
+		if len(got.TagValues) != 1 || got.TagValues[0] != "a" {
+			t.Fatalf("collect %d: tag values %v", i, got.TagValues)
+		}
+	}
+}
+
+func TestItemKinds(t *testing.T) {
+	tests := []struct {
+		name   string
+		metric Metric
+		want   dto.Kind
+	}{
+		{"item", NewItem(ItemOpts{Category: "c", SubCategory: "s", ItemName: "i"}), dto.KindUntyped},
+		{"gauge", NewGauge(GaugeOpts{Category: "c", SubCategory: "s", ItemName: "i"}), dto.KindGauge},
+		{"counter", NewCounter(CounterOpts{Category: "c", SubCategory: "s", ItemName: "i"}), dto.KindCounter},
+		{"delta counter", NewDeltaCounter(DeltaCounterOpts{Category: "c", SubCategory: "s", ItemName: "i"}), dto.KindDeltaCounter},
+	}
+	for _, tt := range tests {
+		var out dto.Metric
+		if err := tt.metric.Write(&out); err != nil {
+			t.Fatal(err)
+		}
+		if out.Kind != tt.want {
+			t.Errorf("%s: kind %s, want %s", tt.name, out.Kind, tt.want)
+		}
+		if out.Category != "c" || out.SubCategory != "s" || out.ItemName != "i" {
+			t.Errorf("%s: name %s.%s.%s", tt.name, out.Category, out.SubCategory, out.ItemName)
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package metric
+
+import (
+	"math"
+	"sort"
+	"sync/atomic"
+
+	"github.com/winey-dev/telemetry/dto"
+)
+
+// DefBuckets는 Buckets를 지정하지 않았을 때 사용하는 기본 버킷(초 단위 응답 시간)이다.
+var DefBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Histogram은 관측 값을 버킷별로 누적한다. 값은 Write 이후에도 유지된다.
+type Histogram interface {
+	Metric
+	Collector
+
+	Observe(float64)
+}
+
+type HistogramOpts struct {
+	Category       string
+	SubCategory    string
+	ItemName       string
+	Description    string
+	ConstraintTags ConstraintTags
+
+	// Buckets는 버킷의 상한 값 목록으로 오름차순이어야 한다. +Inf 버킷은 자동으로 추가된다.
+	Buckets []float64
+}
+
+func NewHistogram(opts HistogramOpts) Histogram {
+	if opts.Category == "" || opts.SubCategory == "" || opts.ItemName == "" {
+		panic(ErrRequiredFields.Error())
+	}
+	if !opts.ConstraintTags.IsEmpty() && !opts.ConstraintTags.IsValid() {
+		panic(ErrInvalidTagValues.Error())
+	}
+	desc := NewDesc(opts.Category, opts.SubCategory, opts.ItemName, opts.Description, opts.ConstraintTags)
+	return newHistogram(desc, upperBounds(opts.Buckets), nil)
+}
+
+func newHistogram(desc *Desc, bounds []float64, tagValues []string) *histogram {
+	result := &histogram{
+		desc:        desc,
+		tagValues:   tagValues,
+		upperBounds: bounds,
+		counts:      make([]uint64, len(bounds)+1),
+	}
+	result.init(result)
+	return result
+}
+
+// upperBounds는 buckets가 오름차순인지 확인하고 마지막 +Inf는 제거한다.
+func upperBounds(buckets []float64) []float64 {
+	if len(buckets) == 0 {
+		buckets = DefBuckets
+	}
+	if math.IsInf(buckets[len(buckets)-1], 1) {
+		buckets = buckets[:len(buckets)-1]
+	}
+	for i := 1; i < len(buckets); i++ {
+		if buckets[i] <= buckets[i-1] {
+			panic(ErrInvalidBuckets.Error())
+		}
+	}
+	bounds := make([]float64, len(buckets))
+	copy(bounds, buckets)
+	return bounds
+}
+
+type histogram struct {
+	sumBits uint64
+
+	selfCollector
+	desc        *Desc
+	tagValues   []string
+	upperBounds []float64
+	// counts[i]는 (upperBounds[i-1], upperBounds[i]] 구간의 개수이며 마지막은 +Inf 구간이다.
+	counts []uint64
+	err    error
+}
+
+func (h *histogram) Desc() *Desc {
+	return h.desc
+}
+
+// Observe는 값을 해당 버킷에 기록한다. 잠금 없이 atomic 연산만 사용한다.
+func (h *histogram) Observe(value float64) {
+	if h.err != nil {
+		return
+	}
+	h.touch()
+	i := sort.SearchFloat64s(h.upperBounds, value)
+	atomic.AddUint64(&h.counts[i], 1)
+	for {
+		oldBits := atomic.LoadUint64(&h.sumBits)
+		newBits := math.Float64bits(math.Float64frombits(oldBits) + value)
+		if atomic.CompareAndSwapUint64(&h.sumBits, oldBits, newBits) {
+			return
+		}
+	}
+}
+
+func (h *histogram) Write(out *dto.Metric) error {
+	return h.Read(out)
+}
+
+// implement Reader interface
+func (h *histogram) Read(out *dto.Metric) error {
+	if h.err != nil {
+		return h.err
+	}
+	writeDesc(out, h.desc, h.tagValues)
+	out.Kind = dto.KindHistogram
+
+	// Count는 버킷 개수의 합으로 계산하여 버킷과 항상 일치하도록 한다.
+	buckets := make([]dto.Bucket, len(h.upperBounds))
+	var cumulative uint64
+	for i, bound := range h.upperBounds {
+		cumulative += atomic.LoadUint64(&h.counts[i])
+		buckets[i] = dto.Bucket{UpperBound: bound, Count: cumulative}
+	}
+	cumulative += atomic.LoadUint64(&h.counts[len(h.upperBounds)])
+
+	out.Histogram = &dto.Histogram{
+		Count:   cumulative,
+		Sum:     math.Float64frombits(atomic.LoadUint64(&h.sumBits)),
+		Buckets: buckets,
+	}
+	return nil
+}
+
+type HistogramVec struct {
+	*MetricVec
+}
+
+func NewHistogramVec(opts HistogramOpts, tagNames ...string) *HistogramVec {
+	if len(tagNames) == 0 {
+		panic("tagNames must not be empty")
+	}
+	bounds := upperBounds(opts.Buckets)
+	desc := NewDesc(opts.Category, opts.SubCategory, opts.ItemName, opts.Description, opts.ConstraintTags, tagNames...)
+	return &HistogramVec{
+		MetricVec: NewMetricVec(desc, func(tagValues ...string) Metric {
+			if len(tagValues) != len(desc.TagNames) {
+				panic("tagValues length does not match tagNames length")
+			}
+			return newHistogram(desc, bounds, tagValues)
+		}),
+	}
+}
+
+func (v *HistogramVec) WithTagValues(tagValues ...string) Histogram {
+	metric, err := v.GetMetricWithTagValues(tagValues...)
+	if err != nil {
+		return &histogram{err: err}
+	}
+	return metric
+}
+
+func (v *HistogramVec) GetMetricWithTagValues(tagValues ...string) (Histogram, error) {
+	metric, err := v.MetricVec.WithTagValues(tagValues...)
+	if err != nil {
+		return nil, err
+	}
+	return metric.(Histogram), nil
+}
+
+func (v *HistogramVec) With(tags map[string]string) Histogram {
+	metric, err := v.GetMetricWith(tags)
+	if err != nil {
+		return &histogram{err: err}
+	}
+	return metric
+}
+
+func (v *HistogramVec) GetMetricWith(tags map[string]string) (Histogram, error) {
+	metric, err := v.MetricVec.GetMetricWith(tags)
+	if err != nil {
+		return nil, err
+	}
+	return metric.(Histogram), nil
+}
+
+func (v *HistogramVec) CurryWith(tags map[string]string) (*HistogramVec, error) {
+	vec, err := v.MetricVec.CurryWith(tags)
+	if err != nil {
+		return nil, err
+	}
+	return &HistogramVec{MetricVec: vec}, nil
+}
+
+// LinearBuckets는 start부터 width 간격으로 count개의 버킷을 생성한다.
+func LinearBuckets(start, width float64, count int) []float64 {
+	if count < 1 {
+		panic("LinearBuckets needs a positive count")
+	}
+	buckets := make([]float64, count)
+	for i := range buckets {
+		buckets[i] = start
+		start += width
+	}
+	return buckets
+}
+
+// ExponentialBuckets는 start부터 factor 배수로 count개의 버킷을 생성한다.
+func ExponentialBuckets(start, factor float64, count int) []float64 {
+	if count < 1 {
+		panic("ExponentialBuckets needs a positive count")
+	}
+	if start <= 0 {
+		panic("ExponentialBuckets needs a positive start value")
+	}
+	if factor <= 1 {
+		panic("ExponentialBuckets needs a factor greater than 1")
+	}
+	buckets := make([]float64, count)
+	for i := range buckets {
+		buckets[i] = start
+		start *= factor
+	}
+	return buckets
+}
@@ -0,0 +1,88 @@
+package metric
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/winey-dev/telemetry/dto"
+)
+
+func TestUpperBounds(t *testing.T) {
+	tests := []struct {
+		name    string
+		buckets []float64
+		want    []float64
+		panics  bool
+	}{
+		{name: "default", buckets: nil, want: DefBuckets},
+		{name: "ascending", buckets: []float64{1, 2, 5}, want: []float64{1, 2, 5}},
+		{name: "trailing +Inf", buckets: []float64{1, 2, math.Inf(1)}, want: []float64{1, 2}},
+		{name: "duplicate", buckets: []float64{1, 1, 2}, panics: true},
+		{name: "descending", buckets: []float64{2, 1}, panics: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				r := recover()
+				if tt.panics && r != ErrInvalidBuckets.Error() {
+					t.Fatalf("recovered %v, want %q", r, ErrInvalidBuckets.Error())
+				}
+				if !tt.panics && r != nil {
+					t.Fatalf("unexpected panic: %v", r)
+				}
+			}()
+			got := upperBounds(tt.buckets)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("upperBounds(%v) = %v, want %v", tt.buckets, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpperBoundsCopiesBuckets(t *testing.T) {
+	buckets := []float64{1, 2}
+	bounds := upperBounds(buckets)
+	buckets[0] = 10
+	if bounds[0] != 1 {
+		t.Fatalf("bounds share the caller's slice: %v", bounds)
+	}
+}
+
+func TestHistogramObserve(t *testing.T) {
+	h := NewHistogram(HistogramOpts{Category: "c", SubCategory: "s", ItemName: "i", Buckets: []float64{1, 5}})
+	// 경계 값은 해당 버킷(le)에 포함되고 가장 큰 상한보다 큰 값은 +Inf 버킷에만 포함된다.
+	for _, v := range []float64{0.5, 1, 3, 5, 7, 100} {
+		h.Observe(v)
+	}
+
+	for i := 0; i < 2; i++ {
+		got := collectOne(t, h)
+		if got.Kind != dto.KindHistogram {
+			t.Fatalf("kind %s, want histogram", got.Kind)
+		}
+		want := &dto.Histogram{
+			Count:   6,
+			Sum:     116.5,
+			Buckets: []dto.Bucket{{UpperBound: 1, Count: 2}, {UpperBound: 5, Count: 4}},
+		}
+		if !reflect.DeepEqual(got.Histogram, want) {
+			t.Fatalf("collect %d: histogram %+v, want %+v", i, got.Histogram, want)
+		}
+	}
+}
+
+func TestHistogramVecStripsInfBucket(t *testing.T) {
+	vec := NewHistogramVec(HistogramOpts{Category: "c", SubCategory: "s", ItemName: "i", Buckets: []float64{1, math.Inf(1)}}, "t")
+	vec.WithTagValues("a").Observe(1)
+	vec.WithTagValues("b").Observe(2)
+
+	for _, m := range collect(t, vec) {
+		if len(m.Histogram.Buckets) != 1 || m.Histogram.Buckets[0].UpperBound != 1 {
+			t.Fatalf("%v: buckets %+v, want only le=1", m.TagValues, m.Histogram.Buckets)
+		}
+		if m.Histogram.Count != 1 {
+			t.Fatalf("%v: count %d, want 1", m.TagValues, m.Histogram.Count)
+		}
+	}
+}
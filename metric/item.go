@@ -18,7 +18,7 @@ type Item interface {
 	Sub(float64)
 	Min(float64)
 	Max(float64)
-	//Avg(float64)
+	// 평균은 AvgItem(NewAvgItem)을 사용한다.
 
 	IsError() bool
 	Error() error
@@ -27,6 +27,10 @@ type Item interface {
 type ItemOpts Opts
 
 func NewItem(opts ItemOpts) *item {
+	return newItem(Opts(opts), dto.KindUntyped)
+}
+
+func newItem(opts Opts, kind dto.Kind) *item {
 	if opts.Category == "" || opts.SubCategory == "" || opts.ItemName == "" {
 		panic(ErrRequiredFields.Error())
 	}
@@ -34,7 +38,7 @@ func NewItem(opts ItemOpts) *item {
 		panic(ErrInvalidTagValues.Error())
 	}
 	desc := NewDesc(opts.Category, opts.SubCategory, opts.ItemName, opts.Description, opts.ConstraintTags)
-	result := &item{desc: desc}
+	result := &item{desc: desc, kind: kind}
 	result.init(result)
 	return result
 }
@@ -45,6 +49,7 @@ type item struct {
 
 	selfCollector
 	desc      *Desc
+	kind      dto.Kind
 	tagValues []string
 	err       error
 }
@@ -59,23 +64,36 @@ func (i *item) Write(out *dto.Metric) error {
 		return i.Error()
 	}
 
+	if i.kind == dto.KindCounter || i.kind == dto.KindGauge {
+		return i.Read(out)
+	}
+
 	// Write 호출 시점에 valBits를 원자적으로 읽고 0으로 초기화 시킨다.
 	valBits := atomic.SwapUint64(&i.valBits, 0)
-	val := math.Float64frombits(valBits)
-	//val := math.Float64frombits(atomic.LoadUint64(&i.valBits))
-
-	out.Category = i.desc.Category
-	out.SubCategory = i.desc.SubCategory
-	out.ItemName = i.desc.ItemName
-	out.Description = i.desc.Description
-	out.TagNames = append(i.desc.ConstraintTags.TagNames, i.desc.TagNames...)
-	out.TagValues = append(i.desc.ConstraintTags.TagValues, i.tagValues...)
-	out.Value = val
+	i.write(out, math.Float64frombits(valBits))
+	return nil
+}
+
+// implement Reader interface
+func (i *item) Read(out *dto.Metric) error {
+	if i.IsError() {
+		return i.Error()
+	}
+
+	// Write와 달리 값을 초기화하지 않는다.
+	i.write(out, math.Float64frombits(atomic.LoadUint64(&i.valBits)))
 	return nil
 }
 
+func (i *item) write(out *dto.Metric, val float64) {
+	writeDesc(out, i.desc, i.tagValues)
+	out.Kind = i.kind
+	out.Value = val
+}
+
 // implement Item interface
 func (i *item) Set(value float64) {
+	i.touch()
 	atomic.StoreUint64(&i.valBits, math.Float64bits(value))
 }
 
@@ -87,6 +105,7 @@ func (i *item) Dec() {
 	i.Add(-1)
 }
 func (i *item) Add(value float64) {
+	i.touch()
 	for {
 		oldBits := atomic.LoadUint64(&i.valBits)
 		newBits := math.Float64bits(math.Float64frombits(oldBits) + value)
@@ -101,6 +120,7 @@ func (i *item) Sub(value float64) {
 }
 
 func (i *item) Min(value float64) {
+	i.touch()
 	for {
 		oldBits := atomic.LoadUint64(&i.valBits)
 		oldValue := math.Float64frombits(oldBits)
@@ -115,6 +135,7 @@ func (i *item) Min(value float64) {
 }
 
 func (i *item) Max(value float64) {
+	i.touch()
 	for {
 		oldBits := atomic.LoadUint64(&i.valBits)
 		oldValue := math.Float64frombits(oldBits)
@@ -143,27 +164,69 @@ type ItemVec struct {
 // 동적 태그 밸류를 갖는 아이템 벡터를 생성하기 위한 생성자
 // 고정 태그 + 동적 태그 밸류를 안전하기 관리하기 위해 Hash 및 Map을 사용
 func NewItemVec(opts ItemOpts, tagNames ...string) *ItemVec {
+	return &ItemVec{
+		MetricVec: newItemMetricVec(Opts(opts), dto.KindUntyped, func(i *item) Metric { return i }, tagNames...),
+	}
+}
+
+// newItemMetricVec은 kind 별 Vec 생성자에서 공통으로 사용한다. wrap은 생성된 item을 kind에 맞는 Metric으로 감싼다.
+func newItemMetricVec(opts Opts, kind dto.Kind, wrap func(*item) Metric, tagNames ...string) *MetricVec {
 	if len(tagNames) == 0 {
 		panic("tagNames must not be empty")
 	}
 	desc := NewDesc(opts.Category, opts.SubCategory, opts.ItemName, opts.Description, opts.ConstraintTags, tagNames...)
-	return &ItemVec{
-		MetricVec: NewMetricVec(desc, func(tagValues ...string) Metric {
-			if len(tagValues) != len(desc.TagNames) {
-				// panic을 사용하지 않고 errorMetric 구조를 반환하도록 변경
-				panic("tagValues length does not match tagNames length")
-			}
-			result := &item{desc: desc, tagValues: tagValues}
-			result.init(result)
-			return result
-		}),
-	}
+	return NewMetricVec(desc, func(tagValues ...string) Metric {
+		if len(tagValues) != len(desc.TagNames) {
+			// panic을 사용하지 않고 errorMetric 구조를 반환하도록 변경
+			panic("tagValues length does not match tagNames length")
+		}
+		result := &item{desc: desc, kind: kind, tagValues: tagValues}
+		result.init(result)
+		return wrap(result)
+	})
 }
 
+// WithTagValues는 tagValues의 Item을 반환한다. 에러가 발생하면 IsError가 true인 Item을 반환한다.
 func (v *ItemVec) WithTagValues(tagValues ...string) Item {
+	metric, err := v.GetMetricWithTagValues(tagValues...)
+	if err != nil {
+		return &item{err: err}
+	}
+	return metric
+}
+
+// GetMetricWithTagValues는 WithTagValues와 같지만 에러가 발생하면 placeholder 대신 에러를 반환한다.
+func (v *ItemVec) GetMetricWithTagValues(tagValues ...string) (Item, error) {
 	metric, err := v.MetricVec.WithTagValues(tagValues...)
+	if err != nil {
+		return nil, err
+	}
+	return metric.(Item), nil
+}
+
+// With는 태그 이름과 값의 map으로 Item을 반환한다. 에러가 발생하면 IsError가 true인 Item을 반환한다.
+func (v *ItemVec) With(tags map[string]string) Item {
+	metric, err := v.GetMetricWith(tags)
 	if err != nil {
 		return &item{err: err}
 	}
-	return metric.(Item)
+	return metric
+}
+
+// GetMetricWith는 With와 같지만 에러가 발생하면 placeholder 대신 에러를 반환한다.
+func (v *ItemVec) GetMetricWith(tags map[string]string) (Item, error) {
+	metric, err := v.MetricVec.GetMetricWith(tags)
+	if err != nil {
+		return nil, err
+	}
+	return metric.(Item), nil
+}
+
+// CurryWith는 tags의 값을 고정한 ItemVec을 반환한다. series는 v와 공유한다.
+func (v *ItemVec) CurryWith(tags map[string]string) (*ItemVec, error) {
+	vec, err := v.MetricVec.CurryWith(tags)
+	if err != nil {
+		return nil, err
+	}
+	return &ItemVec{MetricVec: vec}, nil
 }
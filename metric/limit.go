@@ -0,0 +1,43 @@
+package metric
+
+import "sync/atomic"
+
+// OverflowTagValue는 series 수 제한을 넘어선 태그 값 대신 사용되는 값이다.
+// 제한을 넘어선 태그 값은 모든 태그 값이 OverflowTagValue인 하나의 series로 기록된다.
+const OverflowTagValue = "__overflow__"
+
+// SeriesLimiter는 여러 MetricVec이 공유하는 series 수 제한이다.
+// Registry 전체의 series 수를 제한할 때 사용한다.
+type SeriesLimiter struct {
+	limit    int64
+	count    atomic.Int64
+	rejected atomic.Uint64
+}
+
+// NewSeriesLimiter는 전체 series 수를 limit으로 제한하는 SeriesLimiter를 생성한다. limit이 0 이하면 제한하지 않는다.
+func NewSeriesLimiter(limit int) *SeriesLimiter {
+	return &SeriesLimiter{limit: int64(limit)}
+}
+
+// Series는 현재 series 수를 반환한다.
+func (l *SeriesLimiter) Series() int { return int(l.count.Load()) }
+
+// Rejected는 제한으로 overflow series에 기록된 태그 값의 수를 반환한다.
+func (l *SeriesLimiter) Rejected() uint64 { return l.rejected.Load() }
+
+func (l *SeriesLimiter) acquire() bool {
+	for {
+		count := l.count.Load()
+		if l.limit > 0 && count >= l.limit {
+			l.rejected.Add(1)
+			return false
+		}
+		if l.count.CompareAndSwap(count, count+1) {
+			return true
+		}
+	}
+}
+
+func (l *SeriesLimiter) release(n int) {
+	l.count.Add(-int64(n))
+}
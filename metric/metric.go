@@ -7,6 +7,12 @@ type Metric interface {
 	Write(*dto.Metric) error
 }
 
+// Reader는 값을 초기화하지 않고 현재 값을 읽을 수 있는 Metric이 구현한다.
+// Prometheus와 같이 pull 방식으로 여러 번 읽히는 sink에서 사용한다.
+type Reader interface {
+	Read(*dto.Metric) error
+}
+
 type Opts struct {
 	Category       string
 	SubCategory    string
@@ -14,3 +20,19 @@ type Opts struct {
 	Description    string
 	ConstraintTags ConstraintTags
 }
+
+// writeDesc는 Desc와 태그 정보를 out에 기록한다.
+func writeDesc(out *dto.Metric, desc *Desc, tagValues []string) {
+	out.Category = desc.Category
+	out.SubCategory = desc.SubCategory
+	out.ItemName = desc.ItemName
+	out.Description = desc.Description
+	// ConstraintTags는 여러 Metric이 공유하므로 새 slice에 복사한다.
+	out.TagNames = make([]string, 0, len(desc.ConstraintTags.TagNames)+len(desc.TagNames))
+	out.TagNames = append(out.TagNames, desc.ConstraintTags.TagNames...)
+	out.TagNames = append(out.TagNames, desc.TagNames...)
+	out.TagValues = make([]string, 0, len(desc.ConstraintTags.TagValues)+len(tagValues))
+	out.TagValues = append(out.TagValues, desc.ConstraintTags.TagValues...)
+	out.TagValues = append(out.TagValues, tagValues...)
+	out.ConstraintTags = len(desc.ConstraintTags.TagNames)
+}
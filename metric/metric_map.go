@@ -1,17 +1,87 @@
 package metric
 
-import "sync"
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/winey-dev/telemetry/pkg"
+)
+
+// metricMapShards는 metricMap을 나누는 shard 수이다. series는 태그 값의 hash로 shard에 배치되며,
+// 서로 다른 shard의 조회와 생성은 잠금을 공유하지 않는다.
+const metricMapShards = 16
 
 type metricMap struct {
-	mtx       sync.RWMutex
-	metrics   map[uint64][]metricWithTagValues
+	shards    []metricShard
 	desc      *Desc
 	newMetric func(tagValues ...string) Metric
+
+	// mtx는 아래의 series 수 제한과 ttl 설정을 보호한다.
+	// shard의 잠금을 잡은 상태에서 mtx를 잡을 수 있으며, 반대 순서로는 잡지 않는다.
+	mtx sync.RWMutex
+
+	// series 수 제한. 제한을 넘어선 태그 값은 overflow series로 기록된다.
+	maxSeries int
+	limits    []seriesLimit // Vec을 등록한 Registry마다 하나
+	series    int           // overflow series를 제외한 series 수
+	overflow  Metric
+	rejected  atomic.Uint64
+	// unreported는 마지막 tick 이후 제한된 수이다. 주기마다 한 번 기록한다.
+	unreported atomic.Uint64
+
+	// ttl은 series를 삭제하기 전까지 사용되지 않은 주기 수이다. 0이면 삭제하지 않는다.
+	ttl     uint64
+	onEvict func(tagValues []string, metric Metric)
+	// generation은 tick 횟수이다. series는 마지막으로 사용된 generation을 기록한다.
+	generation atomic.Uint64
+}
+
+// seriesLimit는 Vec에 추가된 SeriesLimiter와 그 limiter에서 획득한 series 수이다.
+type seriesLimit struct {
+	limiter *SeriesLimiter
+	limited int
+}
+
+type metricShard struct {
+	mtx     sync.RWMutex
+	metrics map[uint64][]metricWithTagValues // 처음 series를 생성할 때 만든다.
+
+	// 이웃한 shard의 잠금이 같은 cache line을 사용하지 않도록 한다.
+	_ [32]byte
 }
 
 type metricWithTagValues struct {
-	values []string
-	metric Metric
+	values   []string
+	metric   Metric
+	activity *activity
+}
+
+// activity는 series가 마지막으로 사용된 generation이다. 값을 기록할 때와 WithTagValues로 조회할 때 갱신한다.
+type activity struct {
+	touched    atomic.Uint64
+	generation *atomic.Uint64 // metricMap.generation
+}
+
+func (a *activity) touch() {
+	if a == nil {
+		return
+	}
+	// 같은 주기의 반복된 기록은 읽기만 하여 cache line을 공유하는 goroutine 간의 경합을 줄인다.
+	if generation := a.generation.Load(); a.touched.Load() != generation {
+		a.touched.Store(generation)
+	}
+}
+
+func newMetricMap(desc *Desc, newMetric func(tagValues ...string) Metric, shards int) *metricMap {
+	return &metricMap{
+		shards:    make([]metricShard, shards),
+		desc:      desc,
+		newMetric: newMetric,
+	}
+}
+
+func (m *metricMap) shard(h uint64) *metricShard {
+	return &m.shards[h%uint64(len(m.shards))]
 }
 
 func (m *metricMap) Describe(ch chan<- *Desc) {
@@ -19,71 +89,348 @@ func (m *metricMap) Describe(ch chan<- *Desc) {
 }
 
 func (m *metricMap) Collect(ch chan<- Metric) {
-	m.mtx.RLock()
-	defer m.mtx.RUnlock()
-	for _, metrics := range m.metrics {
-		for _, metric := range metrics {
-			ch <- metric.metric
+	for i := range m.shards {
+		shard := &m.shards[i]
+		shard.mtx.RLock()
+		for _, metrics := range shard.metrics {
+			for _, metric := range metrics {
+				ch <- metric.metric
+			}
 		}
+		shard.mtx.RUnlock()
+	}
+
+	m.mtx.RLock()
+	overflow := m.overflow
+	m.mtx.RUnlock()
+	if overflow != nil {
+		ch <- overflow
 	}
 }
 
 func (m *metricMap) Reset() {
+	for i := range m.shards {
+		shard := &m.shards[i]
+		shard.mtx.Lock()
+		var n int
+		for h, metrics := range shard.metrics {
+			n += len(metrics)
+			delete(shard.metrics, h)
+		}
+		m.mtx.Lock()
+		m.releaseSeries(n)
+		m.mtx.Unlock()
+		shard.mtx.Unlock()
+	}
+
+	m.mtx.Lock()
+	m.overflow = nil
+	m.mtx.Unlock()
+}
+
+// tick은 주기를 하나 진행하여 ttl 동안 사용되지 않은 series를 삭제하고,
+// 지난 주기에 제한된 태그 값이 있으면 한 번 경고한다.
+func (m *metricMap) tick() {
+	m.expire()
+	if n := m.unreported.Swap(0); n > 0 {
+		pkg.DefaultLogger.Warn("Series limit exceeded for %s: %d tag values were recorded as %s", m.desc, n, OverflowTagValue)
+	}
+}
+
+// releaseSeries는 삭제된 n개의 series를 제한에서 제외한다. m.mtx를 잡은 상태에서 호출한다.
+func (m *metricMap) releaseSeries(n int) {
+	m.series -= n
+	for i := range m.limits {
+		limit := &m.limits[i]
+		released := min(n, limit.limited)
+		if released > 0 {
+			limit.limiter.release(released)
+			limit.limited -= released
+		}
+	}
+}
+
+func (m *metricMap) setTTL(intervals int, onEvict func(tagValues []string, metric Metric)) {
 	m.mtx.Lock()
 	defer m.mtx.Unlock()
+	if intervals < 0 {
+		intervals = 0
+	}
+	m.ttl = uint64(intervals)
+	m.onEvict = onEvict
+}
+
+// expire는 ttl 번의 주기 동안 사용되지 않은 series를 삭제하고 onEvict를 호출한다.
+func (m *metricMap) expire() {
+	generation := m.generation.Add(1)
+
+	m.mtx.RLock()
+	ttl := m.ttl
+	m.mtx.RUnlock()
+	if ttl == 0 {
+		return
+	}
 
-	for h := range m.metrics {
-		delete(m.metrics, h)
+	var evicted []metricWithTagValues
+	for i := range m.shards {
+		shard := &m.shards[i]
+		shard.mtx.Lock()
+		n := len(evicted)
+		evicted = shard.filter(evicted, func(metric metricWithTagValues) bool {
+			return generation-metric.activity.touched.Load() > ttl
+		})
+		m.mtx.Lock()
+		m.releaseSeries(len(evicted) - n)
+		m.mtx.Unlock()
+		shard.mtx.Unlock()
+	}
+
+	m.mtx.RLock()
+	onEvict := m.onEvict
+	m.mtx.RUnlock()
+
+	// callback에서 Vec을 사용할 수 있도록 잠금을 해제한 뒤 호출한다.
+	if onEvict != nil {
+		for _, metric := range evicted {
+			onEvict(metric.values, metric.metric)
+		}
 	}
 }
 
-func (m *metricMap) deleteWithTagValues(h uint64, tagValues []string) bool {
+func (m *metricMap) setMaxSeries(n int) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	m.maxSeries = n
+}
+
+// addSeriesLimiter는 limiter를 추가한다. 이미 생성된 series도 limiter의 series 수에 포함되며,
+// 그 결과 limit을 넘더라도 기존 series는 유지되고 이후의 새 태그 값이 제한된다.
+func (m *metricMap) addSeriesLimiter(limiter *SeriesLimiter) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	for _, limit := range m.limits {
+		if limit.limiter == limiter {
+			return
+		}
+	}
+	limiter.count.Add(int64(m.series))
+	m.limits = append(m.limits, seriesLimit{limiter: limiter, limited: m.series})
+}
+
+// removeSeriesLimiter는 limiter를 제거하고 limiter에서 획득한 series를 반환한다.
+func (m *metricMap) removeSeriesLimiter(limiter *SeriesLimiter) {
 	m.mtx.Lock()
 	defer m.mtx.Unlock()
+	for i, limit := range m.limits {
+		if limit.limiter != limiter {
+			continue
+		}
+		limiter.release(limit.limited)
+		m.limits = append(m.limits[:i], m.limits[i+1:]...)
+		return
+	}
+}
+
+// setSeriesLimiter는 모든 limiter를 제거하고 limiter로 교체한다. nil이면 제한을 해제한다.
+func (m *metricMap) setSeriesLimiter(limiter *SeriesLimiter) {
+	m.mtx.Lock()
+	for _, limit := range m.limits {
+		limit.limiter.release(limit.limited)
+	}
+	m.limits = nil
+	m.mtx.Unlock()
+	if limiter != nil {
+		m.addSeriesLimiter(limiter)
+	}
+}
+
+func (m *metricMap) deleteWithTagValues(h uint64, tagValues []string) bool {
+	shard := m.shard(h)
+	shard.mtx.Lock()
+	defer shard.mtx.Unlock()
 
-	metrics, ok := m.metrics[h]
+	metrics, ok := shard.metrics[h]
 	if !ok {
 		return false
 	}
 	i := findMetricWithTagValues(metrics, tagValues)
-	if i >= len(metrics) {
+	if i < 0 {
 		return false
 	}
 
 	if len(metrics) > 1 {
 		old := metrics
-		m.metrics[h] = append(metrics[:i], metrics[i+1:]...)
+		shard.metrics[h] = append(metrics[:i], metrics[i+1:]...)
 		old[len(old)-1] = metricWithTagValues{}
 	} else {
-		delete(m.metrics, h)
+		delete(shard.metrics, h)
 	}
 
-	return false
+	m.mtx.Lock()
+	m.releaseSeries(1)
+	m.mtx.Unlock()
+	return true
 }
 
-func (m *metricMap) getOrCreateWithTagValues(hash uint64, tagValues []string) Metric {
+// deletePartialMatch는 match의 index 위치 태그 값이 모두 같은 series를 삭제하고 삭제된 수를 반환한다.
+// overflow series는 삭제하지 않는다.
+func (m *metricMap) deletePartialMatch(match map[int]string) int {
+	var deleted int
+	for i := range m.shards {
+		shard := &m.shards[i]
+		shard.mtx.Lock()
+		n := len(shard.filter(nil, func(metric metricWithTagValues) bool {
+			return matchPartialTagValues(metric.values, match)
+		}))
+		m.mtx.Lock()
+		m.releaseSeries(n)
+		m.mtx.Unlock()
+		shard.mtx.Unlock()
+		deleted += n
+	}
+	return deleted
+}
+
+// filter는 remove가 true인 series를 삭제하고 removed에 추가하여 반환한다. shard.mtx를 잡은 상태에서 호출한다.
+func (shard *metricShard) filter(removed []metricWithTagValues, remove func(metricWithTagValues) bool) []metricWithTagValues {
+	for h, metrics := range shard.metrics {
+		kept := metrics[:0]
+		for _, metric := range metrics {
+			if remove(metric) {
+				removed = append(removed, metric)
+				continue
+			}
+			kept = append(kept, metric)
+		}
+		for i := len(kept); i < len(metrics); i++ {
+			metrics[i] = metricWithTagValues{}
+		}
+		if len(kept) == 0 {
+			delete(shard.metrics, h)
+		} else {
+			shard.metrics[h] = kept
+		}
+	}
+	return removed
+}
+
+// rangeMetrics는 match와 일치하는 series마다 f를 호출한다. f가 false를 반환하면 중단한다.
+// shard마다 읽기 잠금을 잡은 상태에서 series 목록을 복사한 뒤, 잠금을 해제하고 f를 호출하므로
+// f에서 Vec을 사용해도 된다. 호출 중에 추가된 series는 포함되지 않는다.
+func (m *metricMap) rangeMetrics(match map[int]string, f func(tagValues []string, metric Metric) bool) {
+	var snapshot []metricWithTagValues
+	for i := range m.shards {
+		shard := &m.shards[i]
+		shard.mtx.RLock()
+		for _, metrics := range shard.metrics {
+			for _, metric := range metrics {
+				if matchPartialTagValues(metric.values, match) {
+					snapshot = append(snapshot, metric)
+				}
+			}
+		}
+		shard.mtx.RUnlock()
+	}
 	m.mtx.RLock()
-	metric, ok := m.getMetricWithTagValues(hash, tagValues)
+	if m.overflow != nil && len(match) == 0 {
+		snapshot = append(snapshot, metricWithTagValues{values: overflowTagValues(len(m.desc.TagNames)), metric: m.overflow})
+	}
 	m.mtx.RUnlock()
+
+	for _, metric := range snapshot {
+		if !f(append([]string(nil), metric.values...), metric.metric) {
+			return
+		}
+	}
+}
+
+func (m *metricMap) getOrCreateWithTagValues(hash uint64, tagValues []string) Metric {
+	shard := m.shard(hash)
+	shard.mtx.RLock()
+	metric, ok := m.getMetricWithTagValues(shard, hash, tagValues)
+	shard.mtx.RUnlock()
 	if ok {
 		return metric
 	}
 
+	shard.mtx.Lock()
+	defer shard.mtx.Unlock()
+
+	// 잠금을 기다리는 동안 다른 goroutine이 생성했을 수 있다.
+	if metric, ok := m.getMetricWithTagValues(shard, hash, tagValues); ok {
+		return metric
+	}
+
 	m.mtx.Lock()
-	defer m.mtx.Unlock()
+	if !m.admit() {
+		metric = m.overflowMetric()
+		m.mtx.Unlock()
+		return metric
+	}
+	m.series++
+	m.mtx.Unlock()
 
+	used := &activity{generation: &m.generation}
+	used.touched.Store(m.generation.Load())
 	metric = m.newMetric(tagValues...)
-	m.metrics[hash] = append(m.metrics[hash], metricWithTagValues{
-		values: tagValues,
-		metric: metric,
+	if tracked, ok := metric.(interface{ setActivity(*activity) }); ok {
+		tracked.setActivity(used)
+	}
+	if shard.metrics == nil {
+		shard.metrics = make(map[uint64][]metricWithTagValues)
+	}
+	shard.metrics[hash] = append(shard.metrics[hash], metricWithTagValues{
+		values:   tagValues,
+		metric:   metric,
+		activity: used,
 	})
 	return metric
 }
 
-func (m *metricMap) getMetricWithTagValues(h uint64, tagValues []string) (Metric, bool) {
-	metrics, ok := m.metrics[h]
+// admit은 새 series를 만들 수 있는지 확인한다. m.mtx를 잡은 상태에서 호출한다.
+func (m *metricMap) admit() bool {
+	if m.maxSeries > 0 && m.series >= m.maxSeries {
+		return false
+	}
+	for i, limit := range m.limits {
+		if !limit.limiter.acquire() {
+			// 앞서 획득한 limiter의 series를 되돌린다.
+			for _, acquired := range m.limits[:i] {
+				acquired.limiter.release(1)
+			}
+			return false
+		}
+	}
+	for i := range m.limits {
+		m.limits[i].limited++
+	}
+	return true
+}
+
+// overflowMetric은 제한을 넘어선 태그 값을 기록하는 series를 반환한다. m.mtx를 잡은 상태에서 호출한다.
+func (m *metricMap) overflowMetric() Metric {
+	m.rejected.Add(1)
+	m.unreported.Add(1)
+	if m.overflow == nil {
+		m.overflow = m.newMetric(overflowTagValues(len(m.desc.TagNames))...)
+	}
+	return m.overflow
+}
+
+func overflowTagValues(n int) []string {
+	tagValues := make([]string, n)
+	for i := range tagValues {
+		tagValues[i] = OverflowTagValue
+	}
+	return tagValues
+}
+
+// getMetricWithTagValues는 shard.mtx를 잡은 상태에서 호출한다.
+func (m *metricMap) getMetricWithTagValues(shard *metricShard, h uint64, tagValues []string) (Metric, bool) {
+	metrics, ok := shard.metrics[h]
 	if ok {
-		if i := findMetricWithTagValues(metrics, tagValues); i < len(metrics) {
+		if i := findMetricWithTagValues(metrics, tagValues); i >= 0 {
+			metrics[i].activity.touch()
 			return metrics[i].metric, true
 		}
 	}
@@ -111,3 +458,12 @@ func matchLabelValues(values, tagValues []string) bool {
 	}
 	return true
 }
+
+func matchPartialTagValues(values []string, match map[int]string) bool {
+	for i, value := range match {
+		if values[i] != value {
+			return false
+		}
+	}
+	return true
+}
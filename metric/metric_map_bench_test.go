@@ -0,0 +1,70 @@
+package metric
+
+import (
+	"strconv"
+	"sync/atomic"
+	"testing"
+)
+
+// go test -run '^$' -bench MetricMap -cpu 1,4,16 ./metric 로 shard 수에 따른 경합을 비교한다.
+
+var benchShards = []struct {
+	name   string
+	shards int
+}{
+	{name: "unsharded", shards: 1},
+	{name: "sharded", shards: metricMapShards},
+}
+
+// newBenchVec은 shards 개의 shard를 가진 GaugeVec을 생성한다.
+func newBenchVec(shards int) *GaugeVec {
+	vec := NewGaugeVec(GaugeOpts{Category: "bench", SubCategory: "metric_map", ItemName: "usage"}, "host", "interface")
+	vec.metricMap = newMetricMap(vec.desc, vec.newMetric, shards)
+	return vec
+}
+
+// BenchmarkMetricMapHit은 이미 생성된 series를 조회하여 값을 기록한다.
+func BenchmarkMetricMapHit(b *testing.B) {
+	const series = 1024
+	hosts := make([]string, series)
+	for i := range hosts {
+		hosts[i] = "host-" + strconv.Itoa(i)
+	}
+
+	for _, bc := range benchShards {
+		b.Run(bc.name, func(b *testing.B) {
+			vec := newBenchVec(bc.shards)
+			for _, host := range hosts {
+				vec.WithTagValues(host, "eth0")
+			}
+			var next atomic.Uint64
+			b.ReportAllocs()
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				// goroutine마다 다른 위치에서 시작하여 같은 series만 조회하지 않도록 한다.
+				i := next.Add(series / 16)
+				for pb.Next() {
+					vec.WithTagValues(hosts[i%series], "eth0").Inc()
+					i++
+				}
+			})
+		})
+	}
+}
+
+// BenchmarkMetricMapMiss는 매번 새 태그 값으로 series를 생성한다.
+func BenchmarkMetricMapMiss(b *testing.B) {
+	for _, bc := range benchShards {
+		b.Run(bc.name, func(b *testing.B) {
+			vec := newBenchVec(bc.shards)
+			var next atomic.Uint64
+			b.ReportAllocs()
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					vec.WithTagValues("host-"+strconv.FormatUint(next.Add(1), 10), "eth0").Inc()
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package metric
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/winey-dev/telemetry/dto"
+)
+
+func TestConcurrentCreateSameTagValues(t *testing.T) {
+	const goroutines = 64
+	for _, bc := range benchShards {
+		t.Run(bc.name, func(t *testing.T) {
+			vec := newBenchVec(bc.shards)
+			metrics := make([]Gauge, goroutines)
+			start := make(chan struct{})
+			var wg sync.WaitGroup
+			for i := range metrics {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					<-start
+					metrics[i] = vec.WithTagValues("h1", "eth0")
+					metrics[i].Inc()
+				}()
+			}
+			close(start)
+			wg.Wait()
+
+			for i, m := range metrics {
+				if m != metrics[0] {
+					t.Fatalf("goroutine %d got a different child", i)
+				}
+			}
+			var children int
+			vec.Range(func([]string, Metric) bool {
+				children++
+				return true
+			})
+			if children != 1 {
+				t.Fatalf("children = %d, want 1", children)
+			}
+			if vec.metricMap.series != 1 {
+				t.Fatalf("series = %d, want 1", vec.metricMap.series)
+			}
+
+			var out dto.Metric
+			if err := metrics[0].Write(&out); err != nil {
+				t.Fatalf("write: %v", err)
+			}
+			if out.Value != goroutines {
+				t.Fatalf("value = %v, want %d", out.Value, goroutines)
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package metric
+
+import (
+	"testing"
+
+	"github.com/winey-dev/telemetry/dto"
+)
+
+// collect는 c의 Collect 결과를 Write하여 반환한다. Registry.Gather가 주기마다 한 번 수행하는 것과 같다.
+func collect(t *testing.T, c Collector) []dto.Metric {
+	t.Helper()
+	ch := make(chan Metric)
+	go func() {
+		c.Collect(ch)
+		close(ch)
+	}()
+	var out []dto.Metric
+	for m := range ch {
+		var value dto.Metric
+		if err := m.Write(&value); err != nil {
+			t.Fatal(err)
+		}
+		out = append(out, value)
+	}
+	return out
+}
+
+// collectOne은 series가 하나인 c의 값을 반환한다.
+func collectOne(t *testing.T, c Collector) dto.Metric {
+	t.Helper()
+	values := collect(t, c)
+	if len(values) != 1 {
+		t.Fatalf("collected %d metrics, want 1", len(values))
+	}
+	return values[0]
+}
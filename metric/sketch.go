@@ -0,0 +1,163 @@
+package metric
+
+import "math"
+
+// ddSketch는 상대 오차(relativeAccuracy)가 보장되는 DDSketch 구현이다.
+//
+// 값 v(>0)는 ceil(log_gamma(v)) 인덱스의 bin에 기록되며, 인덱스 i의 대표 값은
+// 2*gamma^i/(gamma+1)이다. bin 개수가 maxBins를 넘으면 가장 작은 bin부터 합쳐진다.
+type ddSketch struct {
+	gamma    float64
+	logGamma float64
+
+	positive binStore
+	negative binStore
+	zero     uint64
+	count    uint64
+	sum      float64
+	min      float64
+	max      float64
+}
+
+const (
+	defaultRelativeAccuracy = 0.01
+	defaultMaxBins          = 2048
+	// minIndexableValue보다 작은 절대값은 0으로 취급한다.
+	minIndexableValue = 1e-9
+)
+
+func newDDSketch(relativeAccuracy float64, maxBins int) *ddSketch {
+	if relativeAccuracy <= 0 || relativeAccuracy >= 1 {
+		relativeAccuracy = defaultRelativeAccuracy
+	}
+	if maxBins <= 0 {
+		maxBins = defaultMaxBins
+	}
+	gamma := (1 + relativeAccuracy) / (1 - relativeAccuracy)
+	return &ddSketch{
+		gamma:    gamma,
+		logGamma: math.Log(gamma),
+		positive: binStore{maxBins: maxBins},
+		negative: binStore{maxBins: maxBins},
+		min:      math.Inf(1),
+		max:      math.Inf(-1),
+	}
+}
+
+func (s *ddSketch) index(v float64) int {
+	return int(math.Ceil(math.Log(v) / s.logGamma))
+}
+
+func (s *ddSketch) value(index int) float64 {
+	return 2 * math.Pow(s.gamma, float64(index)) / (s.gamma + 1)
+}
+
+func (s *ddSketch) add(v float64) {
+	if math.IsNaN(v) {
+		return
+	}
+	switch {
+	case v > minIndexableValue:
+		s.positive.add(s.index(v), 1)
+	case v < -minIndexableValue:
+		s.negative.add(s.index(-v), 1)
+	default:
+		s.zero++
+	}
+	s.count++
+	s.sum += v
+	s.min = math.Min(s.min, v)
+	s.max = math.Max(s.max, v)
+}
+
+// quantile은 q(0 <= q <= 1) 분위수의 추정 값을 반환한다. 기록된 값이 없으면 NaN이다.
+func (s *ddSketch) quantile(q float64) float64 {
+	if s.count == 0 || q < 0 || q > 1 {
+		return math.NaN()
+	}
+	if q == 0 {
+		return s.min
+	}
+	if q == 1 {
+		return s.max
+	}
+
+	rank := uint64(q * float64(s.count-1))
+	var seen uint64
+
+	// 음수는 절대값이 큰 bin부터 순회한다.
+	for k := len(s.negative.bins) - 1; k >= 0; k-- {
+		seen += s.negative.bins[k]
+		if seen > rank {
+			return s.clamp(-s.value(s.negative.offset + k))
+		}
+	}
+	seen += s.zero
+	if seen > rank {
+		return 0
+	}
+	for k, count := range s.positive.bins {
+		seen += count
+		if seen > rank {
+			return s.clamp(s.value(s.positive.offset + k))
+		}
+	}
+	return s.max
+}
+
+func (s *ddSketch) clamp(v float64) float64 {
+	return math.Max(s.min, math.Min(s.max, v))
+}
+
+// binStore는 연속된 index의 bin을 index 순서로 저장한다. bins[k]는 index offset+k의 count이다.
+// 범위가 maxBins를 넘으면 가장 작은 index의 bin들을 범위의 첫 bin으로 합친다.
+type binStore struct {
+	bins    []uint64
+	offset  int
+	maxBins int
+}
+
+func (b *binStore) add(index int, count uint64) {
+	b.extend(index, index)
+	if index < b.offset {
+		index = b.offset
+	}
+	b.bins[index-b.offset] += count
+}
+
+// extend는 [low, high] 범위를 포함하도록 bins를 늘린다. 범위는 높은 index 기준으로 maxBins까지 유지한다.
+func (b *binStore) extend(low, high int) {
+	if len(b.bins) == 0 {
+		if high-low+1 > b.maxBins {
+			low = high - b.maxBins + 1
+		}
+		b.bins = make([]uint64, high-low+1)
+		b.offset = low
+		return
+	}
+
+	last := b.offset + len(b.bins) - 1
+	if low >= b.offset && high <= last {
+		return
+	}
+	low = min(low, b.offset)
+	high = max(high, last)
+	if high-low+1 > b.maxBins {
+		low = high - b.maxBins + 1
+	}
+	if low == b.offset {
+		b.bins = append(b.bins, make([]uint64, high-last)...)
+		return
+	}
+
+	bins := make([]uint64, high-low+1)
+	for k, count := range b.bins {
+		index := b.offset + k
+		if index < low {
+			index = low
+		}
+		bins[index-low] += count
+	}
+	b.bins = bins
+	b.offset = low
+}
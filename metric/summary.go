@@ -0,0 +1,207 @@
+package metric
+
+import (
+	"sync"
+
+	"github.com/winey-dev/telemetry/dto"
+)
+
+// DefObjectives는 Objectives를 지정하지 않았을 때 계산하는 기본 분위수이다.
+var DefObjectives = []float64{0.5, 0.9, 0.99}
+
+// Summary는 수집 주기 동안 관측한 값의 분위수를 계산한다.
+// Write 호출 시점에 sketch가 초기화되어 다음 주기의 관측을 새로 시작한다.
+type Summary interface {
+	Metric
+	Collector
+
+	Observe(float64)
+}
+
+type SummaryOpts struct {
+	Category       string
+	SubCategory    string
+	ItemName       string
+	Description    string
+	ConstraintTags ConstraintTags
+
+	// Objectives는 계산할 분위수 목록(0~1)이다.
+	Objectives []float64
+	// RelativeAccuracy는 분위수 값의 상대 오차 한계이다. 0이면 0.01(1%)을 사용한다.
+	RelativeAccuracy float64
+	// MaxBins는 sketch가 유지하는 bin의 최대 개수이다. 0이면 2048을 사용한다.
+	MaxBins int
+}
+
+func NewSummary(opts SummaryOpts) Summary {
+	if opts.Category == "" || opts.SubCategory == "" || opts.ItemName == "" {
+		panic(ErrRequiredFields.Error())
+	}
+	if !opts.ConstraintTags.IsEmpty() && !opts.ConstraintTags.IsValid() {
+		panic(ErrInvalidTagValues.Error())
+	}
+	desc := NewDesc(opts.Category, opts.SubCategory, opts.ItemName, opts.Description, opts.ConstraintTags)
+	return newSummary(desc, &opts, summaryObjectives(opts.Objectives), nil)
+}
+
+// summaryObjectives는 objectives를 검증한다. 비어 있으면 DefObjectives를 반환한다.
+func summaryObjectives(objectives []float64) []float64 {
+	if len(objectives) == 0 {
+		return DefObjectives
+	}
+	for _, q := range objectives {
+		if q < 0 || q > 1 {
+			panic(ErrInvalidObjectives.Error())
+		}
+	}
+	return objectives
+}
+
+func newSummary(desc *Desc, opts *SummaryOpts, objectives []float64, tagValues []string) *summary {
+	result := &summary{
+		desc:       desc,
+		tagValues:  tagValues,
+		objectives: objectives,
+		accuracy:   opts.RelativeAccuracy,
+		maxBins:    opts.MaxBins,
+	}
+	result.sketch = result.newSketch()
+	result.init(result)
+	return result
+}
+
+type summary struct {
+	mtx    sync.Mutex
+	sketch *ddSketch
+
+	selfCollector
+	desc       *Desc
+	tagValues  []string
+	objectives []float64
+	accuracy   float64
+	maxBins    int
+	err        error
+}
+
+func (s *summary) newSketch() *ddSketch {
+	return newDDSketch(s.accuracy, s.maxBins)
+}
+
+func (s *summary) Desc() *Desc {
+	return s.desc
+}
+
+func (s *summary) Observe(value float64) {
+	if s.err != nil {
+		return
+	}
+	s.touch()
+	s.mtx.Lock()
+	s.sketch.add(value)
+	s.mtx.Unlock()
+}
+
+func (s *summary) Write(out *dto.Metric) error {
+	if s.err != nil {
+		return s.err
+	}
+
+	// Write 호출 시점에 sketch를 교체하여 주기별 분위수를 계산한다.
+	s.mtx.Lock()
+	sketch := s.sketch
+	s.sketch = s.newSketch()
+	s.mtx.Unlock()
+
+	s.write(out, sketch)
+	return nil
+}
+
+// implement Reader interface
+func (s *summary) Read(out *dto.Metric) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.write(out, s.sketch)
+	return nil
+}
+
+func (s *summary) write(out *dto.Metric, sketch *ddSketch) {
+	writeDesc(out, s.desc, s.tagValues)
+	out.Kind = dto.KindSummary
+
+	// 관측 값이 없는 주기에는 분위수를 기록하지 않는다.
+	var quantiles []dto.Quantile
+	if sketch.count > 0 {
+		quantiles = make([]dto.Quantile, len(s.objectives))
+		for i, q := range s.objectives {
+			quantiles[i] = dto.Quantile{Quantile: q, Value: sketch.quantile(q)}
+		}
+	}
+	out.Summary = &dto.Summary{
+		Count:     sketch.count,
+		Sum:       sketch.sum,
+		Quantiles: quantiles,
+	}
+}
+
+type SummaryVec struct {
+	*MetricVec
+}
+
+func NewSummaryVec(opts SummaryOpts, tagNames ...string) *SummaryVec {
+	if len(tagNames) == 0 {
+		panic("tagNames must not be empty")
+	}
+	objectives := summaryObjectives(opts.Objectives)
+	desc := NewDesc(opts.Category, opts.SubCategory, opts.ItemName, opts.Description, opts.ConstraintTags, tagNames...)
+	return &SummaryVec{
+		MetricVec: NewMetricVec(desc, func(tagValues ...string) Metric {
+			if len(tagValues) != len(desc.TagNames) {
+				panic("tagValues length does not match tagNames length")
+			}
+			return newSummary(desc, &opts, objectives, tagValues)
+		}),
+	}
+}
+
+func (v *SummaryVec) WithTagValues(tagValues ...string) Summary {
+	metric, err := v.GetMetricWithTagValues(tagValues...)
+	if err != nil {
+		return &summary{err: err}
+	}
+	return metric
+}
+
+func (v *SummaryVec) GetMetricWithTagValues(tagValues ...string) (Summary, error) {
+	metric, err := v.MetricVec.WithTagValues(tagValues...)
+	if err != nil {
+		return nil, err
+	}
+	return metric.(Summary), nil
+}
+
+func (v *SummaryVec) With(tags map[string]string) Summary {
+	metric, err := v.GetMetricWith(tags)
+	if err != nil {
+		return &summary{err: err}
+	}
+	return metric
+}
+
+func (v *SummaryVec) GetMetricWith(tags map[string]string) (Summary, error) {
+	metric, err := v.MetricVec.GetMetricWith(tags)
+	if err != nil {
+		return nil, err
+	}
+	return metric.(Summary), nil
+}
+
+func (v *SummaryVec) CurryWith(tags map[string]string) (*SummaryVec, error) {
+	vec, err := v.MetricVec.CurryWith(tags)
+	if err != nil {
+		return nil, err
+	}
+	return &SummaryVec{MetricVec: vec}, nil
+}
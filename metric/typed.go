@@ -0,0 +1,444 @@
+package metric
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/winey-dev/telemetry/dto"
+)
+
+// IntGauge는 int64 값을 유지한다. float64로 표현할 수 없는 2^53 이상의 값도 정확하게 기록된다.
+type IntGauge interface {
+	Metric
+	Collector
+
+	Set(int64)
+	Inc()
+	Dec()
+	Add(int64)
+	Sub(int64)
+}
+
+// UintCounter는 uint64 누적 카운터이다.
+type UintCounter interface {
+	Metric
+	Collector
+
+	Inc()
+	Add(uint64)
+}
+
+// BoolItem은 마지막으로 설정된 bool 값을 유지한다.
+type BoolItem interface {
+	Metric
+	Collector
+
+	Set(bool)
+}
+
+// StringItem은 마지막으로 설정된 문자열 값(상태 등)을 유지한다.
+type StringItem interface {
+	Metric
+	Collector
+
+	Set(string)
+}
+
+// typedItem은 타입별 아이템이 공통으로 사용하는 Desc, 태그 정보를 갖는다.
+type typedItem struct {
+	selfCollector
+	desc      *Desc
+	tagValues []string
+	err       error
+}
+
+func newTypedItem(opts ItemOpts) typedItem {
+	if opts.Category == "" || opts.SubCategory == "" || opts.ItemName == "" {
+		panic(ErrRequiredFields.Error())
+	}
+	if !opts.ConstraintTags.IsEmpty() && !opts.ConstraintTags.IsValid() {
+		panic(ErrInvalidTagValues.Error())
+	}
+	return typedItem{desc: NewDesc(opts.Category, opts.SubCategory, opts.ItemName, opts.Description, opts.ConstraintTags)}
+}
+
+func (t *typedItem) Desc() *Desc {
+	return t.desc
+}
+
+func (t *typedItem) write(out *dto.Metric, kind dto.Kind, valueType dto.ValueType) {
+	writeDesc(out, t.desc, t.tagValues)
+	out.Kind = kind
+	out.ValueType = valueType
+}
+
+// newTypedMetricVec은 타입별 Vec 생성자에서 공통으로 사용한다.
+func newTypedMetricVec(opts ItemOpts, newMetric func(typedItem) Metric, tagNames ...string) *MetricVec {
+	if len(tagNames) == 0 {
+		panic("tagNames must not be empty")
+	}
+	desc := NewDesc(opts.Category, opts.SubCategory, opts.ItemName, opts.Description, opts.ConstraintTags, tagNames...)
+	return NewMetricVec(desc, func(tagValues ...string) Metric {
+		if len(tagValues) != len(desc.TagNames) {
+			panic("tagValues length does not match tagNames length")
+		}
+		return newMetric(typedItem{desc: desc, tagValues: tagValues})
+	})
+}
+
+type intGauge struct {
+	val atomic.Int64
+	typedItem
+}
+
+func NewIntGauge(opts ItemOpts) IntGauge {
+	return newIntGauge(newTypedItem(opts))
+}
+
+func newIntGauge(t typedItem) *intGauge {
+	result := &intGauge{typedItem: t}
+	result.init(result)
+	return result
+}
+
+func (g *intGauge) Inc()            { g.Add(1) }
+func (g *intGauge) Dec()            { g.Add(-1) }
+func (g *intGauge) Sub(value int64) { g.Add(-value) }
+
+func (g *intGauge) Set(value int64) {
+	g.touch()
+	g.val.Store(value)
+}
+
+func (g *intGauge) Add(value int64) {
+	g.touch()
+	g.val.Add(value)
+}
+
+func (g *intGauge) Write(out *dto.Metric) error {
+	return g.Read(out)
+}
+
+// implement Reader interface
+func (g *intGauge) Read(out *dto.Metric) error {
+	if g.err != nil {
+		return g.err
+	}
+	g.write(out, dto.KindGauge, dto.ValueInt)
+	out.IntValue = g.val.Load()
+	out.Value = float64(out.IntValue)
+	return nil
+}
+
+type uintCounter struct {
+	val atomic.Uint64
+	typedItem
+}
+
+func NewUintCounter(opts ItemOpts) UintCounter {
+	return newUintCounter(newTypedItem(opts))
+}
+
+func newUintCounter(t typedItem) *uintCounter {
+	result := &uintCounter{typedItem: t}
+	result.init(result)
+	return result
+}
+
+func (c *uintCounter) Inc() { c.Add(1) }
+
+func (c *uintCounter) Add(value uint64) {
+	c.touch()
+	c.val.Add(value)
+}
+
+func (c *uintCounter) Write(out *dto.Metric) error {
+	return c.Read(out)
+}
+
+// implement Reader interface
+func (c *uintCounter) Read(out *dto.Metric) error {
+	if c.err != nil {
+		return c.err
+	}
+	c.write(out, dto.KindCounter, dto.ValueUint)
+	out.UintValue = c.val.Load()
+	out.Value = float64(out.UintValue)
+	return nil
+}
+
+type boolItem struct {
+	val atomic.Bool
+	typedItem
+}
+
+func NewBoolItem(opts ItemOpts) BoolItem {
+	return newBoolItem(newTypedItem(opts))
+}
+
+func newBoolItem(t typedItem) *boolItem {
+	result := &boolItem{typedItem: t}
+	result.init(result)
+	return result
+}
+
+func (b *boolItem) Set(value bool) {
+	b.touch()
+	b.val.Store(value)
+}
+
+func (b *boolItem) Write(out *dto.Metric) error {
+	return b.Read(out)
+}
+
+// implement Reader interface
+func (b *boolItem) Read(out *dto.Metric) error {
+	if b.err != nil {
+		return b.err
+	}
+	b.write(out, dto.KindGauge, dto.ValueBool)
+	out.BoolValue = b.val.Load()
+	if out.BoolValue {
+		out.Value = 1
+	}
+	return nil
+}
+
+type stringItem struct {
+	mtx sync.RWMutex
+	val string
+	typedItem
+}
+
+func NewStringItem(opts ItemOpts) StringItem {
+	return newStringItem(newTypedItem(opts))
+}
+
+func newStringItem(t typedItem) *stringItem {
+	result := &stringItem{typedItem: t}
+	result.init(result)
+	return result
+}
+
+func (s *stringItem) Set(value string) {
+	s.touch()
+	s.mtx.Lock()
+	s.val = value
+	s.mtx.Unlock()
+}
+
+func (s *stringItem) Write(out *dto.Metric) error {
+	return s.Read(out)
+}
+
+// implement Reader interface
+func (s *stringItem) Read(out *dto.Metric) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.write(out, dto.KindGauge, dto.ValueString)
+	s.mtx.RLock()
+	out.StringValue = s.val
+	s.mtx.RUnlock()
+	return nil
+}
+
+type IntGaugeVec struct {
+	*MetricVec
+}
+
+func NewIntGaugeVec(opts ItemOpts, tagNames ...string) *IntGaugeVec {
+	return &IntGaugeVec{
+		MetricVec: newTypedMetricVec(opts, func(t typedItem) Metric { return newIntGauge(t) }, tagNames...),
+	}
+}
+
+func (v *IntGaugeVec) WithTagValues(tagValues ...string) IntGauge {
+	metric, err := v.GetMetricWithTagValues(tagValues...)
+	if err != nil {
+		return &intGauge{typedItem: typedItem{err: err}}
+	}
+	return metric
+}
+
+func (v *IntGaugeVec) GetMetricWithTagValues(tagValues ...string) (IntGauge, error) {
+	metric, err := v.MetricVec.WithTagValues(tagValues...)
+	if err != nil {
+		return nil, err
+	}
+	return metric.(IntGauge), nil
+}
+
+func (v *IntGaugeVec) With(tags map[string]string) IntGauge {
+	metric, err := v.GetMetricWith(tags)
+	if err != nil {
+		return &intGauge{typedItem: typedItem{err: err}}
+	}
+	return metric
+}
+
+func (v *IntGaugeVec) GetMetricWith(tags map[string]string) (IntGauge, error) {
+	metric, err := v.MetricVec.GetMetricWith(tags)
+	if err != nil {
+		return nil, err
+	}
+	return metric.(IntGauge), nil
+}
+
+func (v *IntGaugeVec) CurryWith(tags map[string]string) (*IntGaugeVec, error) {
+	vec, err := v.MetricVec.CurryWith(tags)
+	if err != nil {
+		return nil, err
+	}
+	return &IntGaugeVec{MetricVec: vec}, nil
+}
+
+type UintCounterVec struct {
+	*MetricVec
+}
+
+func NewUintCounterVec(opts ItemOpts, tagNames ...string) *UintCounterVec {
+	return &UintCounterVec{
+		MetricVec: newTypedMetricVec(opts, func(t typedItem) Metric { return newUintCounter(t) }, tagNames...),
+	}
+}
+
+func (v *UintCounterVec) WithTagValues(tagValues ...string) UintCounter {
+	metric, err := v.GetMetricWithTagValues(tagValues...)
+	if err != nil {
+		return &uintCounter{typedItem: typedItem{err: err}}
+	}
+	return metric
+}
+
+func (v *UintCounterVec) GetMetricWithTagValues(tagValues ...string) (UintCounter, error) {
+	metric, err := v.MetricVec.WithTagValues(tagValues...)
+	if err != nil {
+		return nil, err
+	}
+	return metric.(UintCounter), nil
+}
+
+func (v *UintCounterVec) With(tags map[string]string) UintCounter {
+	metric, err := v.GetMetricWith(tags)
+	if err != nil {
+		return &uintCounter{typedItem: typedItem{err: err}}
+	}
+	return metric
+}
+
+func (v *UintCounterVec) GetMetricWith(tags map[string]string) (UintCounter, error) {
+	metric, err := v.MetricVec.GetMetricWith(tags)
+	if err != nil {
+		return nil, err
+	}
+	return metric.(UintCounter), nil
+}
+
+func (v *UintCounterVec) CurryWith(tags map[string]string) (*UintCounterVec, error) {
+	vec, err := v.MetricVec.CurryWith(tags)
+	if err != nil {
+		return nil, err
+	}
+	return &UintCounterVec{MetricVec: vec}, nil
+}
+
+type BoolItemVec struct {
+	*MetricVec
+}
+
+func NewBoolItemVec(opts ItemOpts, tagNames ...string) *BoolItemVec {
+	return &BoolItemVec{
+		MetricVec: newTypedMetricVec(opts, func(t typedItem) Metric { return newBoolItem(t) }, tagNames...),
+	}
+}
+
+func (v *BoolItemVec) WithTagValues(tagValues ...string) BoolItem {
+	metric, err := v.GetMetricWithTagValues(tagValues...)
+	if err != nil {
+		return &boolItem{typedItem: typedItem{err: err}}
+	}
+	return metric
+}
+
+func (v *BoolItemVec) GetMetricWithTagValues(tagValues ...string) (BoolItem, error) {
+	metric, err := v.MetricVec.WithTagValues(tagValues...)
+	if err != nil {
+		return nil, err
+	}
+	return metric.(BoolItem), nil
+}
+
+func (v *BoolItemVec) With(tags map[string]string) BoolItem {
+	metric, err := v.GetMetricWith(tags)
+	if err != nil {
+		return &boolItem{typedItem: typedItem{err: err}}
+	}
+	return metric
+}
+
+func (v *BoolItemVec) GetMetricWith(tags map[string]string) (BoolItem, error) {
+	metric, err := v.MetricVec.GetMetricWith(tags)
+	if err != nil {
+		return nil, err
+	}
+	return metric.(BoolItem), nil
+}
+
+func (v *BoolItemVec) CurryWith(tags map[string]string) (*BoolItemVec, error) {
+	vec, err := v.MetricVec.CurryWith(tags)
+	if err != nil {
+		return nil, err
+	}
+	return &BoolItemVec{MetricVec: vec}, nil
+}
+
+type StringItemVec struct {
+	*MetricVec
+}
+
+func NewStringItemVec(opts ItemOpts, tagNames ...string) *StringItemVec {
+	return &StringItemVec{
+		MetricVec: newTypedMetricVec(opts, func(t typedItem) Metric { return newStringItem(t) }, tagNames...),
+	}
+}
+
+func (v *StringItemVec) WithTagValues(tagValues ...string) StringItem {
+	metric, err := v.GetMetricWithTagValues(tagValues...)
+	if err != nil {
+		return &stringItem{typedItem: typedItem{err: err}}
+	}
+	return metric
+}
+
+func (v *StringItemVec) GetMetricWithTagValues(tagValues ...string) (StringItem, error) {
+	metric, err := v.MetricVec.WithTagValues(tagValues...)
+	if err != nil {
+		return nil, err
+	}
+	return metric.(StringItem), nil
+}
+
+func (v *StringItemVec) With(tags map[string]string) StringItem {
+	metric, err := v.GetMetricWith(tags)
+	if err != nil {
+		return &stringItem{typedItem: typedItem{err: err}}
+	}
+	return metric
+}
+
+func (v *StringItemVec) GetMetricWith(tags map[string]string) (StringItem, error) {
+	metric, err := v.MetricVec.GetMetricWith(tags)
+	if err != nil {
+		return nil, err
+	}
+	return metric.(StringItem), nil
+}
+
+func (v *StringItemVec) CurryWith(tags map[string]string) (*StringItemVec, error) {
+	vec, err := v.MetricVec.CurryWith(tags)
+	if err != nil {
+		return nil, err
+	}
+	return &StringItemVec{MetricVec: vec}, nil
+}
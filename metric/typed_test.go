@@ -0,0 +1,69 @@
+package metric
+
+import (
+	"testing"
+
+	"github.com/winey-dev/telemetry/dto"
+)
+
+func TestTypedItemValueTypes(t *testing.T) {
+	opts := ItemOpts{Category: "c", SubCategory: "s", ItemName: "i"}
+
+	intGauge := NewIntGauge(opts)
+	intGauge.Set(-7)
+	intGauge.Inc()
+	uintCounter := NewUintCounter(opts)
+	uintCounter.Add(1 << 60)
+	uintCounter.Inc()
+	boolItem := NewBoolItem(opts)
+	boolItem.Set(true)
+	stringItem := NewStringItem(opts)
+	stringItem.Set("running")
+
+	tests := []struct {
+		name      string
+		metric    Metric
+		kind      dto.Kind
+		valueType dto.ValueType
+		want      any
+	}{
+		{"int", intGauge, dto.KindGauge, dto.ValueInt, int64(-6)},
+		{"uint", uintCounter, dto.KindCounter, dto.ValueUint, uint64(1<<60 + 1)},
+		{"bool", boolItem, dto.KindGauge, dto.ValueBool, true},
+		{"string", stringItem, dto.KindGauge, dto.ValueString, "running"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 값은 Write 이후에도 유지된다.
+			for i := 0; i < 2; i++ {
+				var out dto.Metric
+				if err := tt.metric.Write(&out); err != nil {
+					t.Fatal(err)
+				}
+				if out.Kind != tt.kind || out.ValueType != tt.valueType {
+					t.Fatalf("kind %s, value type %d, want %s, %d", out.Kind, out.ValueType, tt.kind, tt.valueType)
+				}
+				if got := out.TypedValue(); got != tt.want {
+					t.Fatalf("write %d: typed value %v (%T), want %v (%T)", i, got, got, tt.want, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestTypedItemVecTagValues(t *testing.T) {
+	vec := NewStringItemVec(ItemOpts{Category: "c", SubCategory: "s", ItemName: "i"}, "t")
+	vec.WithTagValues("a").Set("up")
+	vec.With(map[string]string{"t": "b"}).Set("down")
+
+	got := map[string]string{}
+	for _, m := range collect(t, vec) {
+		if m.ValueType != dto.ValueString {
+			t.Fatalf("%v: value type %d, want string", m.TagValues, m.ValueType)
+		}
+		got[m.TagValues[0]] = m.StringValue
+	}
+	if len(got) != 2 || got["a"] != "up" || got["b"] != "down" {
+		t.Fatalf("values %v, want a=up b=down", got)
+	}
+}
@@ -1,19 +1,28 @@
 package metric
 
+import (
+	"fmt"
+	"sort"
+)
+
 type MetricVec struct {
 	*metricMap
 
+	// curry는 CurryWith로 고정된 태그 값이다. TagNames의 index 순서로 정렬되어 있다.
+	curry []curriedTagValue
+
 	hashAdd     func(h uint64, s string) uint64
 	hashAddByte func(h uint64, b byte) uint64
 }
 
+type curriedTagValue struct {
+	index int
+	value string
+}
+
 func NewMetricVec(desc *Desc, newMetric func(tagValues ...string) Metric) *MetricVec {
 	return &MetricVec{
-		metricMap: &metricMap{
-			metrics:   make(map[uint64][]metricWithTagValues),
-			desc:      desc,
-			newMetric: newMetric,
-		},
+		metricMap:   newMetricMap(desc, newMetric, metricMapShards),
 		hashAdd:     hashAdd,
 		hashAddByte: hashAddByte,
 	}
@@ -26,8 +35,43 @@ func (m *MetricVec) Collect(ch chan<- Metric) { m.metricMap.Collect(ch) }
 // Reset deletes all metrics in this vector.
 func (m *MetricVec) Reset() { m.metricMap.Reset() }
 
+// SetMaxSeries는 Vec이 가질 수 있는 series 수를 n으로 제한한다. 0이면 제한하지 않는다.
+// 제한을 넘어선 태그 값은 모든 태그 값이 OverflowTagValue인 하나의 series로 기록된다.
+func (m *MetricVec) SetMaxSeries(n int) { m.metricMap.setMaxSeries(n) }
+
+// SetSeriesLimiter는 다른 Vec과 공유하는 series 수 제한을 limiter 하나로 설정한다. nil이면 제한을 해제한다.
+// 이미 생성된 series도 limiter의 series 수에 포함된다.
+func (m *MetricVec) SetSeriesLimiter(limiter *SeriesLimiter) { m.metricMap.setSeriesLimiter(limiter) }
+
+// AddSeriesLimiter는 series 수 제한을 추가한다. 추가된 limiter는 모두 적용되며 이미 생성된 series도 포함된다.
+// Registry에 SeriesLimit이 설정되어 있으면 Register 시점에 호출되므로, 여러 Registry에 등록된 Vec은 각 Registry의 제한을 따른다.
+func (m *MetricVec) AddSeriesLimiter(limiter *SeriesLimiter) { m.metricMap.addSeriesLimiter(limiter) }
+
+// RemoveSeriesLimiter는 AddSeriesLimiter로 추가한 limiter를 제거하고 limiter에서 획득한 series를 반환한다.
+func (m *MetricVec) RemoveSeriesLimiter(limiter *SeriesLimiter) {
+	m.metricMap.removeSeriesLimiter(limiter)
+}
+
+// Tick은 agent의 주기가 끝날 때 Registry가 호출한다.
+// SetTTL의 주기를 진행하고, 지난 주기에 series 수 제한으로 overflow series에 기록된 태그 값이 있으면 한 번 경고한다.
+func (m *MetricVec) Tick() { m.metricMap.tick() }
+
+// SetTTL은 intervals 번의 주기 동안 값이 기록되지 않고 WithTagValues로도 조회되지 않은 series를 삭제한다.
+// 주기는 Tick 호출, 즉 Vec을 등록한 agent의 수집 주기이다. 여러 agent에 등록된 Vec은 각 agent의 Tick마다 진행된다.
+// 0이면 삭제하지 않는다. onEvict가 nil이 아니면 삭제된 series마다 호출된다.
+// 삭제된 series의 Metric을 보관하여 사용하면 이후의 값은 기록되지 않으므로, 오래 사용되지 않을 수 있는 series는
+// 값을 기록할 때 WithTagValues로 다시 조회한다.
+func (m *MetricVec) SetTTL(intervals int, onEvict func(tagValues []string, metric Metric)) {
+	m.metricMap.setTTL(intervals, onEvict)
+}
+
+// Rejected는 series 수 제한으로 overflow series에 기록된 태그 값의 수를 반환한다.
+func (m *MetricVec) Rejected() uint64 { return m.metricMap.rejected.Load() }
+
+// DeletTagValues는 tagValues의 series를 삭제한다. curry된 Vec이면 고정되지 않은 태그 값만 전달한다.
 func (m *MetricVec) DeletTagValues(tagValues ...string) bool {
-	if len(tagValues) != len(m.desc.TagNames) {
+	tagValues, err := m.fullTagValues(tagValues)
+	if err != nil {
 		return false
 	}
 
@@ -38,9 +82,47 @@ func (m *MetricVec) DeletTagValues(tagValues ...string) bool {
 	return m.deleteWithTagValues(h, tagValues)
 }
 
+// DeletePartialMatch는 tags의 태그 값을 모두 가진 series를 삭제하고 삭제된 수를 반환한다.
+// 예를 들어 {"interface": "eth1"}이면 interface가 eth1인 모든 series를 삭제한다.
+// curry된 Vec이면 고정된 태그 값과도 일치하는 series만 삭제하며, 알 수 없는 태그가 있으면 0을 반환한다.
+func (m *MetricVec) DeletePartialMatch(tags map[string]string) int {
+	match, ok := m.partialMatch(tags)
+	if !ok {
+		return 0
+	}
+	return m.deletePartialMatch(match)
+}
+
+// Range는 series마다 f를 호출한다. f가 false를 반환하면 중단한다.
+// tagValues는 TagNames 순서의 모든 태그 값이며, curry된 Vec이면 고정된 태그 값과 일치하는 series만 전달한다.
+// series 목록은 호출 시점에 복사되므로 f에서 Vec을 사용해도 된다.
+func (m *MetricVec) Range(f func(tagValues []string, metric Metric) bool) {
+	match, _ := m.partialMatch(nil)
+	m.rangeMetrics(match, f)
+}
+
+// WithTagValues는 tagValues의 Metric을 반환한다. 없으면 생성한다.
+// curry된 Vec이면 고정되지 않은 태그 값만 TagNames 순서로 전달한다.
 func (m *MetricVec) WithTagValues(tagValues ...string) (Metric, error) {
-	if len(tagValues) != len(m.desc.TagNames) {
-		return nil, ErrInvalidTagValues
+	tagValues, err := m.fullTagValues(tagValues)
+	if err != nil {
+		return nil, err
+	}
+
+	h, err := m.hashTagValues(tagValues)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.getOrCreateWithTagValues(h, tagValues), nil
+}
+
+// GetMetricWith는 태그 이름과 값의 map으로 Metric을 반환한다. 없으면 생성한다.
+// curry된 태그를 제외한 모든 태그가 있어야 하며, 알 수 없는 태그가 있으면 에러를 반환한다.
+func (m *MetricVec) GetMetricWith(tags map[string]string) (Metric, error) {
+	tagValues, err := m.tagValuesOf(tags)
+	if err != nil {
+		return nil, err
 	}
 
 	h, err := m.hashTagValues(tagValues)
@@ -51,6 +133,77 @@ func (m *MetricVec) WithTagValues(tagValues ...string) (Metric, error) {
 	return m.getOrCreateWithTagValues(h, tagValues), nil
 }
 
+// CurryWith는 tags의 값을 고정한 Vec을 반환한다. 반환된 Vec은 series를 원래 Vec과 공유하며,
+// WithTagValues, GetMetricWith에는 고정되지 않은 태그만 전달한다.
+// 반환된 Vec의 Collect는 원래 Vec의 모든 series를 수집하므로, Registry에는 원래 Vec만 등록한다.
+func (m *MetricVec) CurryWith(tags map[string]string) (*MetricVec, error) {
+	curry := append([]curriedTagValue(nil), m.curry...)
+	for name, value := range tags {
+		index := m.tagIndex(name)
+		if index < 0 {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownTagName, name)
+		}
+		if m.curried(index) {
+			return nil, fmt.Errorf("%w: %s", ErrCurriedTagName, name)
+		}
+		curry = append(curry, curriedTagValue{index: index, value: value})
+	}
+	sort.Slice(curry, func(i, j int) bool { return curry[i].index < curry[j].index })
+
+	return &MetricVec{
+		metricMap:   m.metricMap,
+		curry:       curry,
+		hashAdd:     m.hashAdd,
+		hashAddByte: m.hashAddByte,
+	}, nil
+}
+
+// fullTagValues는 고정되지 않은 태그 값에 curry된 값을 채워 TagNames 순서의 태그 값을 만든다.
+func (m *MetricVec) fullTagValues(tagValues []string) ([]string, error) {
+	if len(tagValues)+len(m.curry) != len(m.desc.TagNames) {
+		return nil, ErrInvalidTagValues
+	}
+	if len(m.curry) == 0 {
+		return tagValues, nil
+	}
+
+	out := make([]string, 0, len(m.desc.TagNames))
+	curry := m.curry
+	for i := range m.desc.TagNames {
+		if len(curry) > 0 && curry[0].index == i {
+			out = append(out, curry[0].value)
+			curry = curry[1:]
+			continue
+		}
+		out = append(out, tagValues[0])
+		tagValues = tagValues[1:]
+	}
+	return out, nil
+}
+
+// tagValuesOf는 태그 이름과 값의 map에 curry된 값을 채워 TagNames 순서의 태그 값을 만든다.
+func (m *MetricVec) tagValuesOf(tags map[string]string) ([]string, error) {
+	out := make([]string, len(m.desc.TagNames))
+	for _, c := range m.curry {
+		out[c.index] = c.value
+	}
+	for name, value := range tags {
+		index := m.tagIndex(name)
+		if index < 0 {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownTagName, name)
+		}
+		if m.curried(index) {
+			return nil, fmt.Errorf("%w: %s", ErrCurriedTagName, name)
+		}
+		out[index] = value
+	}
+	// 모든 key가 고정되지 않은 서로 다른 태그이므로 수가 같으면 빠진 태그가 없다.
+	if len(tags)+len(m.curry) != len(m.desc.TagNames) {
+		return nil, ErrInvalidTagValues
+	}
+	return out, nil
+}
+
 func (m *MetricVec) hashTagValues(tagValues []string) (uint64, error) {
 	var h = hashNew()
 	for i := 0; i < len(m.desc.TagNames); i++ {
@@ -59,3 +212,41 @@ func (m *MetricVec) hashTagValues(tagValues []string) (uint64, error) {
 	}
 	return h, nil
 }
+
+func (m *MetricVec) tagIndex(name string) int {
+	for i, tagName := range m.desc.TagNames {
+		if tagName == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func (m *MetricVec) curried(index int) bool {
+	for _, c := range m.curry {
+		if c.index == index {
+			return true
+		}
+	}
+	return false
+}
+
+// partialMatch는 tags와 curry된 값을 TagNames의 index로 바꾼다.
+// 알 수 없는 태그가 있거나 curry된 값과 다르면 false를 반환한다.
+func (m *MetricVec) partialMatch(tags map[string]string) (map[int]string, bool) {
+	match := make(map[int]string, len(tags)+len(m.curry))
+	for _, c := range m.curry {
+		match[c.index] = c.value
+	}
+	for name, value := range tags {
+		index := m.tagIndex(name)
+		if index < 0 {
+			return nil, false
+		}
+		if curried, ok := match[index]; ok && curried != value {
+			return nil, false
+		}
+		match[index] = value
+	}
+	return match, true
+}
@@ -0,0 +1,255 @@
+package metric
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func newTestItemVec() *ItemVec {
+	return NewItemVec(ItemOpts{Category: "c", SubCategory: "s", ItemName: "i"}, "host", "interface", "direction")
+}
+
+// seriesOf는 Range로 조회한 series의 태그 값을 "host/interface/direction" 형식으로 정렬하여 반환한다.
+func seriesOf(vec *MetricVec) []string {
+	var series []string
+	vec.Range(func(tagValues []string, _ Metric) bool {
+		series = append(series, strings.Join(tagValues, "/"))
+		return true
+	})
+	sort.Strings(series)
+	return series
+}
+
+func TestCurryWith(t *testing.T) {
+	vec := newTestItemVec()
+	host, err := vec.CurryWith(map[string]string{"host": "h1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rx, err := host.CurryWith(map[string]string{"direction": "rx"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// curry된 Vec은 고정되지 않은 태그만 TagNames 순서로 받으며 series를 원래 Vec과 공유한다.
+	rx.WithTagValues("eth0").Set(1)
+	host.With(map[string]string{"interface": "eth1", "direction": "tx"}).Set(2)
+	if got := vec.WithTagValues("h1", "eth0", "rx"); got != rx.WithTagValues("eth0") {
+		t.Fatal("curried vec created a different child")
+	}
+	want := []string{"h1/eth0/rx", "h1/eth1/tx"}
+	if got := seriesOf(vec.MetricVec); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("series %v, want %v", got, want)
+	}
+	if got := seriesOf(rx.MetricVec); len(got) != 1 || got[0] != "h1/eth0/rx" {
+		t.Fatalf("curried range %v, want [h1/eth0/rx]", got)
+	}
+}
+
+func TestCurryWithConflicts(t *testing.T) {
+	vec := newTestItemVec()
+	host, err := vec.CurryWith(map[string]string{"host": "h1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		tags map[string]string
+		want error
+	}{
+		{"unknown tag", map[string]string{"zone": "a"}, ErrUnknownTagName},
+		{"already curried", map[string]string{"host": "h2"}, ErrCurriedTagName},
+		{"already curried with the same value", map[string]string{"host": "h1"}, ErrCurriedTagName},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := host.CurryWith(tt.tags); !errors.Is(err, tt.want) {
+				t.Fatalf("CurryWith(%v) err = %v, want %v", tt.tags, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithTagValuesCountMismatch(t *testing.T) {
+	vec := newTestItemVec()
+	curried, err := vec.CurryWith(map[string]string{"host": "h1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		vec       *ItemVec
+		tagValues []string
+	}{
+		{"too few", vec, []string{"h1", "eth0"}},
+		{"too many", vec, []string{"h1", "eth0", "rx", "extra"}},
+		{"curried value passed again", curried, []string{"h1", "eth0", "rx"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.vec.GetMetricWithTagValues(tt.tagValues...); !errors.Is(err, ErrInvalidTagValues) {
+				t.Fatalf("err = %v, want %v", err, ErrInvalidTagValues)
+			}
+			if !tt.vec.WithTagValues(tt.tagValues...).IsError() {
+				t.Fatal("WithTagValues did not return an error item")
+			}
+			if tt.vec.DeletTagValues(tt.tagValues...) {
+				t.Fatal("DeletTagValues returned true")
+			}
+		})
+	}
+	if got := seriesOf(vec.MetricVec); len(got) != 0 {
+		t.Fatalf("invalid tag values created series %v", got)
+	}
+}
+
+func TestGetMetricWithErrors(t *testing.T) {
+	vec := newTestItemVec()
+	curried, err := vec.CurryWith(map[string]string{"host": "h1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		vec  *ItemVec
+		tags map[string]string
+		want error
+	}{
+		{"missing tag", vec, map[string]string{"host": "h1", "interface": "eth0"}, ErrInvalidTagValues},
+		{"unknown tag", vec, map[string]string{"host": "h1", "interface": "eth0", "zone": "a"}, ErrUnknownTagName},
+		{"curried tag", curried, map[string]string{"host": "h2", "interface": "eth0", "direction": "rx"}, ErrCurriedTagName},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := tt.vec.GetMetricWith(tt.tags)
+			if !errors.Is(err, tt.want) || m != nil {
+				t.Fatalf("GetMetricWith(%v) = %v, %v, want nil, %v", tt.tags, m, err, tt.want)
+			}
+			item := tt.vec.With(tt.tags)
+			if !item.IsError() {
+				t.Fatal("With did not return an error item")
+			}
+			// placeholder에 기록한 값은 무시되고 Write는 에러를 반환한다.
+			item.Set(1)
+			if err := item.Write(nil); !errors.Is(err, tt.want) {
+				t.Fatalf("placeholder Write err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+	if got := seriesOf(vec.MetricVec); len(got) != 0 {
+		t.Fatalf("invalid tags created series %v", got)
+	}
+}
+
+func TestDeletePartialMatch(t *testing.T) {
+	vec := newTestItemVec()
+	for _, host := range []string{"h1", "h2"} {
+		for _, iface := range []string{"eth0", "eth1"} {
+			for _, direction := range []string{"rx", "tx"} {
+				vec.WithTagValues(host, iface, direction).Set(1)
+			}
+		}
+	}
+
+	if n := vec.DeletePartialMatch(map[string]string{"zone": "a"}); n != 0 {
+		t.Fatalf("unknown tag deleted %d series", n)
+	}
+	if n := vec.DeletePartialMatch(map[string]string{"interface": "eth1"}); n != 4 {
+		t.Fatalf("deleted %d series, want 4", n)
+	}
+	if n := vec.DeletePartialMatch(map[string]string{"interface": "eth1"}); n != 0 {
+		t.Fatalf("second delete removed %d series, want 0", n)
+	}
+
+	// curry된 Vec은 고정된 태그 값과 일치하는 series만 삭제한다.
+	h1, err := vec.CurryWith(map[string]string{"host": "h1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := h1.DeletePartialMatch(map[string]string{"direction": "rx"}); n != 1 {
+		t.Fatalf("curried delete removed %d series, want 1", n)
+	}
+	if n := h1.DeletePartialMatch(map[string]string{"host": "h2"}); n != 0 {
+		t.Fatalf("delete conflicting with the curried value removed %d series", n)
+	}
+
+	want := []string{"h1/eth0/tx", "h2/eth0/rx", "h2/eth0/tx"}
+	if got := seriesOf(vec.MetricVec); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("series %v, want %v", got, want)
+	}
+	if !vec.DeletTagValues("h1", "eth0", "tx") {
+		t.Fatal("DeletTagValues returned false for an existing series")
+	}
+	if vec.DeletTagValues("h1", "eth0", "tx") {
+		t.Fatal("DeletTagValues returned true for a deleted series")
+	}
+}
+
+func TestRangeStops(t *testing.T) {
+	vec := newTestItemVec()
+	vec.WithTagValues("h1", "eth0", "rx").Set(1)
+	vec.WithTagValues("h1", "eth0", "tx").Set(2)
+	vec.WithTagValues("h2", "eth0", "rx").Set(3)
+
+	var calls int
+	vec.Range(func(tagValues []string, m Metric) bool {
+		calls++
+		if len(tagValues) != 3 || m.(Item).IsError() {
+			t.Fatalf("range got %v", tagValues)
+		}
+		// f에서 Vec을 사용해도 교착되지 않는다.
+		vec.WithTagValues("h3", "eth0", "rx")
+		return false
+	})
+	if calls != 1 {
+		t.Fatalf("range called f %d times after false, want 1", calls)
+	}
+	if got := seriesOf(vec.MetricVec); len(got) != 4 {
+		t.Fatalf("series %v, want 4", got)
+	}
+}
+
+func TestDeleteReleasesLimiterSeries(t *testing.T) {
+	limiter := NewSeriesLimiter(2)
+	vec := newTestItemVec()
+	vec.SetSeriesLimiter(limiter)
+
+	vec.WithTagValues("h1", "eth0", "rx").Set(1)
+	vec.WithTagValues("h1", "eth1", "rx").Set(1)
+	vec.WithTagValues("h2", "eth0", "rx").Set(1)
+	if got := limiter.Series(); got != 2 {
+		t.Fatalf("limiter series = %d, want 2", got)
+	}
+	if got := limiter.Rejected(); got != 1 {
+		t.Fatalf("rejected = %d, want 1", got)
+	}
+
+	if !vec.DeletTagValues("h1", "eth0", "rx") {
+		t.Fatal("DeletTagValues failed")
+	}
+	if got := limiter.Series(); got != 1 {
+		t.Fatalf("limiter series after delete = %d, want 1", got)
+	}
+	// 반환된 series만큼 새 태그 값을 받을 수 있다.
+	vec.WithTagValues("h2", "eth0", "rx").Set(1)
+	if got := limiter.Rejected(); got != 1 {
+		t.Fatalf("rejected after delete = %d, want 1", got)
+	}
+
+	if n := vec.DeletePartialMatch(map[string]string{"direction": "rx"}); n != 2 {
+		t.Fatalf("deleted %d series, want 2", n)
+	}
+	if got := limiter.Series(); got != 0 {
+		t.Fatalf("limiter series after partial delete = %d, want 0", got)
+	}
+	// overflow series는 삭제되지 않는다.
+	overflow := strings.Join([]string{OverflowTagValue, OverflowTagValue, OverflowTagValue}, "/")
+	if got := seriesOf(vec.MetricVec); len(got) != 1 || got[0] != overflow {
+		t.Fatalf("series %v, want only the overflow series", got)
+	}
+}
@@ -0,0 +1,378 @@
+package register
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/winey-dev/telemetry/dto"
+	"github.com/winey-dev/telemetry/metric"
+	"github.com/winey-dev/telemetry/pkg"
+)
+
+const (
+	minRetryInterval = 1 * time.Second
+	maxRetryInterval = 30 * time.Second
+
+	exporterTag = "exporter"
+)
+
+// Exporter는 한 주기에 수집된 snapshot을 외부 저장소로 전송한다.
+// 같은 snapshot이 모든 Exporter에 전달되므로 metrics를 수정해서는 안 된다.
+// 재시도 시 같은 snapshot으로 다시 호출되므로 Export는 여러 번 호출되어도 안전해야 한다.
+type Exporter interface {
+	Export(ctx context.Context, metrics []dto.Metric, now time.Time) error
+}
+
+// FailureHandler를 구현한 Exporter는 재시도를 모두 소진했거나 이전 전송이 끝나지 않아
+// 버려진 snapshot을 전달받는다. 디스크 백업 등 Exporter 별 복구 처리에 사용한다.
+type FailureHandler interface {
+	ExportFailed(metrics []dto.Metric, now time.Time, err error)
+}
+
+// Starter를 구현한 Exporter는 agent의 Start 시점에 Start가 호출된다.
+// 오류는 기록만 하며 Exporter는 이후 Export에서 다시 시도해야 한다.
+type Starter interface {
+	Start(ctx context.Context) error
+}
+
+// AgentConfig는 NewAgent로 생성되는 agent의 설정이다.
+type AgentConfig struct {
+	IntervalSeconds int
+	// RetryAttempts는 Export가 실패했을 때 Exporter 별 재시도 횟수이다.
+	RetryAttempts int
+	// TimeoutSeconds는 Export 한 번의 제한 시간이다. 0이면 IntervalSeconds를 사용한다.
+	TimeoutSeconds int
+	// MaxSeries는 등록된 MetricVec 전체의 series 수 제한이다. 0이면 제한하지 않는다.
+	MaxSeries int
+}
+
+// agent는 주기마다 한 번 Gather하고 같은 snapshot을 여러 Exporter에 동시에 전달한다.
+// Exporter마다 별도의 goroutine에서 재시도하므로 느리거나 실패하는 Exporter가 다른 Exporter에 영향을 주지 않는다.
+type agent struct {
+	Registry
+	config  *AgentConfig
+	sinks   []*sink
+	self    *agentMetrics
+	wg      sync.WaitGroup
+	ctx     context.Context
+	cancel  context.CancelFunc
+	timeout time.Duration
+
+	logger pkg.Logger
+}
+
+type snapshot struct {
+	metrics []dto.Metric
+	now     time.Time
+}
+
+// sink는 하나의 Exporter와 전송 대기 중인 snapshot을 가진다.
+// 대기열은 하나이며 이전 전송이 끝나지 않은 상태에서 새 snapshot이 오면 대기 중인 snapshot을 버린다.
+type sink struct {
+	name     string
+	exporter Exporter
+	pending  chan snapshot
+}
+
+// NewAgent는 exporters로 snapshot을 전송하는 Agent를 생성한다.
+func NewAgent(config *AgentConfig, exporters ...Exporter) (Agent, error) {
+	if config.IntervalSeconds <= 0 {
+		return nil, fmt.Errorf("invalid interval seconds: %d", config.IntervalSeconds)
+	}
+	if len(exporters) == 0 {
+		return nil, fmt.Errorf("at least one exporter is required")
+	}
+	timeout := time.Duration(config.IntervalSeconds) * time.Second
+	if config.TimeoutSeconds > 0 {
+		timeout = time.Duration(config.TimeoutSeconds) * time.Second
+	}
+
+	sinks := make([]*sink, len(exporters))
+	for i, exporter := range exporters {
+		if exporter == nil {
+			return nil, fmt.Errorf("exporter %d is nil", i)
+		}
+		sinks[i] = &sink{
+			name:     exporterName(exporter),
+			exporter: exporter,
+			pending:  make(chan snapshot, 1),
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	a := &agent{
+		config:  config,
+		sinks:   sinks,
+		self:    newAgentMetrics(),
+		ctx:     ctx,
+		cancel:  cancel,
+		timeout: timeout,
+		logger:  pkg.DefaultLogger,
+	}
+	a.SetSeriesLimit(config.MaxSeries)
+	return a, nil
+}
+
+// exporterName은 로그와 self metric에 사용할 Exporter의 이름을 반환한다.
+// fmt.Stringer를 구현하면 String()을 사용한다.
+func exporterName(exporter Exporter) string {
+	if s, ok := exporter.(fmt.Stringer); ok {
+		return s.String()
+	}
+	return fmt.Sprintf("%T", exporter)
+}
+
+func (a *agent) Start() error {
+	for _, s := range a.sinks {
+		starter, ok := s.exporter.(Starter)
+		if !ok {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(a.ctx, a.timeout)
+		if err := starter.Start(ctx); err != nil {
+			a.logger.Error("Failed to start exporter(%s): %v", s.name, err)
+		}
+		cancel()
+	}
+
+	for _, s := range a.sinks {
+		a.wg.Add(1)
+		go func(s *sink) {
+			defer a.wg.Done()
+			a.run(s)
+		}(s)
+	}
+
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+		ticker := time.NewTicker(time.Duration(a.config.IntervalSeconds) * time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case now := <-ticker.C:
+				a.logger.Debug("Gathering metrics at %s", now.Format(time.RFC3339))
+				a.dispatch(snapshot{metrics: a.gather(now), now: now})
+			case <-a.ctx.Done():
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+// Stop은 진행 중인 전송을 취소하고 Closer를 구현한 Exporter를 닫는다.
+func (a *agent) Stop() {
+	a.cancel()
+	a.wg.Wait()
+	for _, s := range a.sinks {
+		select {
+		case pending := <-s.pending:
+			a.failed(s, pending, context.Canceled)
+		default:
+		}
+		if closer, ok := s.exporter.(Closer); ok {
+			closer.Close()
+		}
+	}
+}
+
+func (a *agent) gather(now time.Time) []dto.Metric {
+	ctx, cancel := context.WithTimeout(a.ctx, time.Duration(a.config.IntervalSeconds)*time.Second)
+	defer cancel()
+	started := time.Now()
+	metrics, err := a.Gather(ctx)
+	a.self.gatherDuration.Set(time.Since(started).Seconds())
+	if err != nil {
+		// 일부 collector가 실패하더라도 수집된 결과는 전송한다.
+		a.logger.Error("Failed to gather some metrics: %v", err)
+		var errs MultiError
+		if errors.As(err, &errs) {
+			a.self.gatherErrors.Add(float64(len(errs)))
+		} else {
+			a.self.gatherErrors.Inc()
+		}
+	}
+
+	// Write는 delta 값을 초기화하므로 주기마다 한 번만 호출하여 모든 Exporter가 같은 값을 받도록 한다.
+	values := make([]dto.Metric, 0, len(metrics))
+	for _, m := range append(metrics, a.self.collect()...) {
+		var value dto.Metric
+		if err := m.Write(&value); err != nil {
+			a.logger.Error("Failed to write metric(%s): %v", m.Desc(), err)
+			continue
+		}
+		values = append(values, value)
+	}
+
+	a.Tick()
+	return values
+}
+
+// dispatch는 snapshot을 각 sink의 대기열에 넣는다. 대기 중인 snapshot이 있으면 버리고 새 snapshot으로 교체한다.
+func (a *agent) dispatch(snap snapshot) {
+	for _, s := range a.sinks {
+		select {
+		case dropped := <-s.pending:
+			a.self.droppedSnapshots.WithTagValues(s.name).Inc()
+			a.logger.Warn("Exporter(%s) is busy, dropped snapshot at %s", s.name, dropped.now.Format(time.RFC3339))
+			a.failed(s, dropped, ErrExporterBusy)
+		default:
+		}
+		s.pending <- snap
+	}
+}
+
+func (a *agent) run(s *sink) {
+	for {
+		select {
+		case snap := <-s.pending:
+			if a.ctx.Err() != nil {
+				a.failed(s, snap, a.ctx.Err())
+				return
+			}
+			if err := a.export(s, snap); err != nil {
+				a.logger.Error("Failed to export metrics(%s): %v", s.name, err)
+				a.failed(s, snap, err)
+			}
+		case <-a.ctx.Done():
+			return
+		}
+	}
+}
+
+// export는 snapshot을 전송하고 실패하면 exponential backoff로 RetryAttempts 만큼 재시도한다.
+// Exporter가 ExportError를 반환하면 재시도 여부와 간격은 ExportError를 따른다.
+func (a *agent) export(s *sink, snap snapshot) error {
+	for attempt := 0; ; attempt++ {
+		err := a.exportOnce(s, snap)
+		if err == nil {
+			return nil
+		}
+		a.self.exportFailures.WithTagValues(s.name).Inc()
+		delay := backoff(attempt)
+		var exportErr *ExportError
+		if errors.As(err, &exportErr) {
+			if exportErr.Permanent {
+				return err
+			}
+			if exportErr.RetryAfter > 0 {
+				delay = exportErr.RetryAfter
+			}
+		}
+		if attempt >= a.config.RetryAttempts || a.ctx.Err() != nil {
+			return err
+		}
+
+		a.logger.Warn("Exporter(%s) failed, retrying in %s (%d/%d): %v", s.name, delay, attempt+1, a.config.RetryAttempts, err)
+		a.self.exportRetries.WithTagValues(s.name).Inc()
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-a.ctx.Done():
+			timer.Stop()
+			return a.ctx.Err()
+		}
+	}
+}
+
+// exportOnce는 Export를 한 번 호출한다. Exporter의 panic은 오류로 변환된다.
+func (a *agent) exportOnce(s *sink, snap snapshot) (err error) {
+	ctx, cancel := context.WithTimeout(a.ctx, a.timeout)
+	defer cancel()
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("exporter panic: %v", r)
+		}
+	}()
+	return s.exporter.Export(ctx, snap.metrics, snap.now)
+}
+
+func (a *agent) failed(s *sink, snap snapshot, err error) {
+	handler, ok := s.exporter.(FailureHandler)
+	if !ok {
+		return
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			a.logger.Error("Exporter(%s) panicked while handling a failed export: %v", s.name, r)
+		}
+	}()
+	handler.ExportFailed(snap.metrics, snap.now, err)
+}
+
+func backoff(attempt int) time.Duration {
+	delay := minRetryInterval << attempt
+	if delay <= 0 || delay > maxRetryInterval {
+		delay = maxRetryInterval
+	}
+	// jitter: [delay/2, delay)
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)))
+}
+
+// agentMetrics는 agent의 상태를 나타내는 self metric으로 telemetry.agent.* 이름으로 snapshot에 포함된다.
+// 예약된 카테고리이므로 Registry를 거치지 않고 직접 수집한다.
+type agentMetrics struct {
+	gatherDuration   metric.Gauge
+	gatherErrors     metric.Counter
+	exportFailures   *metric.CounterVec
+	exportRetries    *metric.CounterVec
+	droppedSnapshots *metric.CounterVec
+}
+
+// SelfOpts는 telemetry.agent 카테고리의 self metric Opts를 반환한다.
+// Exporter가 자신의 상태를 나타내는 metric을 만들 때 사용한다.
+func SelfOpts(itemName, description string) metric.Opts {
+	return metric.Opts{
+		Category:    ReservedCategory,
+		SubCategory: ReservedSubCategory,
+		ItemName:    itemName,
+		Description: description,
+	}
+}
+
+func newAgentMetrics() *agentMetrics {
+	return &agentMetrics{
+		gatherDuration: metric.NewGauge(metric.GaugeOpts(SelfOpts("gather_duration_seconds",
+			"Duration of the last Registry.Gather call in seconds."))),
+		gatherErrors: metric.NewCounter(metric.CounterOpts(SelfOpts("gather_errors",
+			"Number of collectors that failed or timed out during Gather."))),
+		exportFailures: metric.NewCounterVec(metric.CounterOpts(SelfOpts("export_failures",
+			"Number of failed Export calls.")), exporterTag),
+		exportRetries: metric.NewCounterVec(metric.CounterOpts(SelfOpts("export_retries",
+			"Number of Export retries.")), exporterTag),
+		droppedSnapshots: metric.NewCounterVec(metric.CounterOpts(SelfOpts("dropped_snapshots",
+			"Number of snapshots dropped because the exporter was still busy.")), exporterTag),
+	}
+}
+
+func (s *agentMetrics) collect() []metric.Metric {
+	return CollectAll(
+		s.gatherDuration,
+		s.gatherErrors,
+		s.exportFailures,
+		s.exportRetries,
+		s.droppedSnapshots,
+	)
+}
+
+// CollectAll은 Registry를 거치지 않고 collectors의 metric을 수집한다.
+func CollectAll(collectors ...metric.Collector) []metric.Metric {
+	var metrics []metric.Metric
+	ch := make(chan metric.Metric)
+	go func() {
+		for _, c := range collectors {
+			c.Collect(ch)
+		}
+		close(ch)
+	}()
+	for m := range ch {
+		metrics = append(metrics, m)
+	}
+	return metrics
+}
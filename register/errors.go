@@ -0,0 +1,92 @@
+package register
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/winey-dev/telemetry/metric"
+)
+
+var (
+	ErrReservedTagName  = errors.New("tag name is reserved")
+	ErrReservedCategory = errors.New("category is reserved")
+	ErrInvalidTagName   = errors.New("invalid tag name")
+	ErrDuplicateTagName = errors.New("duplicate tag name")
+	ErrInvalidTagValues = errors.New("invalid constraint tag values")
+	ErrDuplicateDesc    = errors.New("duplicate desc")
+	ErrInconsistentDesc = errors.New("desc has inconsistent tag names with a previously registered desc")
+	ErrInconsistentHelp = errors.New("desc has inconsistent description with a previously registered desc")
+	ErrExporterBusy     = errors.New("exporter is busy with the previous snapshot")
+)
+
+// AlreadyRegisteredError는 같은 Desc를 가진 collector가 이미 등록되어 있을 때 반환된다.
+// ExistingCollector를 사용하여 이미 등록된 collector를 재사용할 수 있다.
+type AlreadyRegisteredError struct {
+	ExistingCollector metric.Collector
+	NewCollector      metric.Collector
+}
+
+func (e AlreadyRegisteredError) Error() string {
+	return "duplicate metrics collector registration attempted"
+}
+
+// DescError는 collector의 Desc가 유효하지 않거나 등록된 Desc와 충돌할 때 반환된다.
+type DescError struct {
+	Desc *metric.Desc
+	Err  error
+}
+
+func (e *DescError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Desc, e.Err)
+}
+
+func (e *DescError) Unwrap() error {
+	return e.Err
+}
+
+// CollectorError는 Gather 중 실패하거나 시간 내에 완료되지 않은 collector를 나타낸다.
+type CollectorError struct {
+	Name string
+	Err  error
+}
+
+func (e *CollectorError) Error() string {
+	return fmt.Sprintf("collector(%s): %v", e.Name, e.Err)
+}
+
+func (e *CollectorError) Unwrap() error {
+	return e.Err
+}
+
+// ExportError는 Exporter가 재시도 방법을 agent에 알릴 때 반환한다.
+// Permanent가 true이면 재시도하지 않고, RetryAfter가 0보다 크면 backoff 대신 RetryAfter 뒤에 재시도한다.
+type ExportError struct {
+	Err        error
+	RetryAfter time.Duration
+	Permanent  bool
+}
+
+func (e *ExportError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ExportError) Unwrap() error {
+	return e.Err
+}
+
+// MultiError는 여러 collector의 오류를 함께 반환할 때 사용한다.
+type MultiError []error
+
+func (e MultiError) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("%d error(s) occurred: %s", len(e), strings.Join(msgs, "; "))
+}
+
+func (e MultiError) Unwrap() []error {
+	return e
+}
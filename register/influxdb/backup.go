@@ -0,0 +1,268 @@
+package influxdb
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/winey-dev/telemetry/pkg"
+)
+
+const (
+	backupFileExt = ".txt"
+	backupTempExt = ".tmp"
+)
+
+// backup은 재시도 횟수를 모두 소진한 batch를 BackupDir 아래 bucket 이름별 디스크 큐에 저장한다.
+//
+//	<BackupDir>/<bucket>/<seq>.seg
+//	<BackupDir>/<bucket>/cursor
+//
+// 전체 용량(maxBytes)과 보관 기간(maxAge)을 넘어서면 가장 오래된 세그먼트부터 삭제한다.
+// 이전 버전에서 생성한 <BackupDir>/<bucket>.txt 파일은 로드 시 큐로 옮겨진다.
+type backup struct {
+	mtx          sync.Mutex
+	dir          string
+	maxBytes     int64
+	maxAge       time.Duration
+	segmentBytes int64
+	queues       map[string]*queue
+	logger       pkg.Logger
+
+	// onEvict는 크기, 보관 기간 제한으로 세그먼트가 삭제될 때 호출된다.
+	onEvict func(bucketName string, segments int)
+	// onCorrupt는 손상된 record를 잘라낼 때 호출된다.
+	onCorrupt func(bucketName string, discarded int64)
+}
+
+func newBackup(config *Config, logger pkg.Logger) *backup {
+	return &backup{
+		dir:          config.BackupDir,
+		maxBytes:     config.BackupMaxBytes,
+		maxAge:       time.Duration(config.BackupMaxAgeSeconds) * time.Second,
+		segmentBytes: config.BackupSegmentBytes,
+		queues:       make(map[string]*queue),
+		logger:       logger,
+	}
+}
+
+func validBucketName(bucketName string) bool {
+	return bucketName != "" && bucketName != "." && bucketName != ".." && !strings.ContainsAny(bucketName, `/\`)
+}
+
+// queue는 bucketName의 큐를 반환한다. 처음 사용하면 연다. b.mtx를 잡은 상태에서 호출한다.
+func (b *backup) queue(bucketName string) (*queue, error) {
+	if q, ok := b.queues[bucketName]; ok {
+		return q, nil
+	}
+	q, err := openQueue(filepath.Join(b.dir, bucketName), b.segmentBytes, func(path string, offset, discarded int64) {
+		b.logger.Error("Discarded %d bytes of backup segment(%s) from corrupt record at offset %d", discarded, path, offset)
+		if b.onCorrupt != nil {
+			b.onCorrupt(bucketName, discarded)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	b.queues[bucketName] = q
+	return q, nil
+}
+
+// append는 batch를 bucket의 큐에 저장하고 크기와 보관 기간 제한을 적용한다.
+func (b *backup) append(bucketName, batch string) error {
+	if b.dir == "" {
+		return fmt.Errorf("backup directory is not configured")
+	}
+	if !validBucketName(bucketName) {
+		return fmt.Errorf("invalid bucket name: %q", bucketName)
+	}
+
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	q, err := b.queue(bucketName)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	if err := q.append([]byte(batch), now); err != nil {
+		return err
+	}
+	b.enforce(now)
+	return nil
+}
+
+// enforce는 보관 기간이 지난 세그먼트를 삭제한 뒤, 전체 크기가 maxBytes 이하가 될 때까지
+// 모든 큐에서 가장 오래된 세그먼트부터 삭제한다. b.mtx를 잡은 상태에서 호출한다.
+func (b *backup) enforce(now time.Time) {
+	if b.maxAge > 0 {
+		for bucketName, q := range b.queues {
+			removed, err := q.expire(b.maxAge, now)
+			if err != nil {
+				b.logger.Error("Failed to expire backup segments(%s): %v", bucketName, err)
+			}
+			if removed > 0 {
+				b.logger.Warn("Dropped %d expired backup segments(%s)", removed, bucketName)
+				b.evicted(bucketName, removed)
+			}
+		}
+	}
+	if b.maxBytes <= 0 {
+		return
+	}
+
+	total := int64(0)
+	for _, q := range b.queues {
+		total += q.size()
+	}
+	for total > b.maxBytes {
+		var (
+			oldestName string
+			oldestQ    *queue
+			oldestAt   time.Time
+		)
+		for bucketName, q := range b.queues {
+			created, ok := q.oldest()
+			if ok && (oldestQ == nil || created.Before(oldestAt)) {
+				oldestName, oldestQ, oldestAt = bucketName, q, created
+			}
+		}
+		if oldestQ == nil {
+			return
+		}
+		size, err := oldestQ.evictOldest()
+		if err != nil {
+			b.logger.Error("Failed to evict backup segment(%s): %v", oldestName, err)
+			return
+		}
+		b.logger.Warn("Dropped backup segment(%s, %d bytes): backup size limit(%d bytes) exceeded", oldestName, size, b.maxBytes)
+		b.evicted(oldestName, 1)
+		total -= size
+	}
+}
+
+func (b *backup) evicted(bucketName string, segments int) {
+	if b.onEvict != nil {
+		b.onEvict(bucketName, segments)
+	}
+}
+
+// stats는 로드된 큐의 전체 크기와 세그먼트 파일 수를 반환한다.
+func (b *backup) stats() (int64, int) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	var (
+		bytes int64
+		files int
+	)
+	for _, q := range b.queues {
+		size, segments := q.stats()
+		bytes += size
+		files += segments
+	}
+	return bytes, files
+}
+
+// load는 backup 디렉토리의 모든 큐를 열고 이전 버전의 <bucket>.txt 파일을 큐로 옮긴다.
+func (b *backup) load() error {
+	if b.dir == "" {
+		return nil
+	}
+	entries, err := os.ReadDir(b.dir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() {
+			if _, err := b.queue(name); err != nil {
+				b.logger.Error("Failed to open backup queue(%s): %v", name, err)
+			}
+			continue
+		}
+		if !strings.HasSuffix(name, backupFileExt) {
+			continue // 임시 파일
+		}
+		bucketName := strings.TrimSuffix(name, backupFileExt)
+		if !validBucketName(bucketName) {
+			continue
+		}
+		if err := b.migrate(bucketName, filepath.Join(b.dir, name)); err != nil {
+			b.logger.Error("Failed to migrate backup file(%s): %v", name, err)
+		}
+	}
+	b.enforce(time.Now())
+	return nil
+}
+
+func (b *backup) migrate(bucketName, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	q, err := b.queue(bucketName)
+	if err != nil {
+		return err
+	}
+	if len(strings.TrimSpace(string(data))) > 0 {
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		if err := q.append(data, info.ModTime()); err != nil {
+			return err
+		}
+	}
+	if err := os.Remove(path); err != nil {
+		return err
+	}
+	return syncDir(b.dir)
+}
+
+// replay는 bucket마다 오래된 batch부터 send에 전달한다. 전송에 실패한 batch는 큐에 남아 다음 호출에서
+// 다시 전송되며 그 bucket의 재전송은 멈춘다. send가 errDiscardRecord를 반환한 batch는 버려진다.
+func (b *backup) replay(send func(bucketName string, batch []byte) error) {
+	if err := b.load(); err != nil {
+		b.logger.Error("Failed to read backup directory(%s): %v", b.dir, err)
+		return
+	}
+
+	b.mtx.Lock()
+	queues := make(map[string]*queue, len(b.queues))
+	for bucketName, q := range b.queues {
+		queues[bucketName] = q
+	}
+	b.mtx.Unlock()
+
+	for bucketName, q := range queues {
+		replayed, err := q.replay(func(batch []byte) error {
+			return send(bucketName, batch)
+		})
+		if replayed > 0 {
+			b.logger.Info("Replayed %d backup batches(%s)", replayed, bucketName)
+		}
+		if err != nil {
+			b.logger.Error("Failed to replay backup(%s): %v", bucketName, err)
+		}
+	}
+}
+
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
@@ -0,0 +1,262 @@
+package influxdb
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/winey-dev/telemetry/metric"
+	"github.com/winey-dev/telemetry/pkg"
+	"github.com/winey-dev/telemetry/register"
+)
+
+// waitFor는 cond가 true가 될 때까지 기다린다.
+func waitFor(t *testing.T, timeout time.Duration, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+// startAgent는 fake 서버로 기록하는 agent를 시작한다. 재시도하지 않으므로 실패한 batch는 바로 backup된다.
+func startAgent(t *testing.T, server *fakeInfluxDB, backupDir string) register.Agent {
+	t.Helper()
+	agent, err := NewRegisterer(&Config{
+		URL:             server.URL,
+		Organization:    testOrganization,
+		IntervalSeconds: 1,
+		BackupDir:       backupDir,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	counter := metric.NewCounter(metric.CounterOpts{Category: "cpu", SubCategory: "core", ItemName: "usage"})
+	if err := agent.Register(counter); err != nil {
+		t.Fatal(err)
+	}
+	counter.Inc()
+	if err := agent.Start(); err != nil {
+		t.Fatal(err)
+	}
+	return agent
+}
+
+func TestBackupDeliversEveryLineOnceAcrossRestart(t *testing.T) {
+	server := newFakeInfluxDB(t)
+	server.buckets["REALTIME_cpu"] = 0
+	server.buckets["REALTIME_"+register.ReservedCategory] = 0
+	// 두 bucket에 대해 세 번의 snapshot을 거부한다.
+	server.failWrites = 6
+	backupDir := t.TempDir()
+
+	agent := startAgent(t, server, backupDir)
+	waitFor(t, 10*time.Second, "rejected writes", func() bool {
+		server.mtx.Lock()
+		defer server.mtx.Unlock()
+		return server.failWrites == 0
+	})
+	agent.Stop()
+	b := newBackup(&Config{BackupDir: backupDir}, pkg.DefaultLogger)
+	if err := b.load(); err != nil {
+		t.Fatal(err)
+	}
+	if _, files := b.stats(); files == 0 {
+		t.Fatal("nothing was backed up before the restart")
+	}
+
+	// 재시작한 agent가 이전 실행에서 backup한 batch를 재전송한다.
+	agent = startAgent(t, server, backupDir)
+	defer agent.Stop()
+
+	server.mtx.Lock()
+	rejected := append([]string(nil), server.rejected...)
+	server.mtx.Unlock()
+	if len(rejected) == 0 {
+		t.Fatal("server rejected no lines")
+	}
+	delivered := func() map[string]int {
+		server.mtx.Lock()
+		defer server.mtx.Unlock()
+		counts := make(map[string]int)
+		for _, lines := range server.lines {
+			for _, line := range lines {
+				counts[line]++
+			}
+		}
+		return counts
+	}
+	waitFor(t, 10*time.Second, "replayed lines", func() bool {
+		counts := delivered()
+		for _, line := range rejected {
+			if counts[line] == 0 {
+				return false
+			}
+		}
+		return true
+	})
+	agent.Stop()
+
+	for line, n := range delivered() {
+		if n != 1 {
+			t.Errorf("line delivered %d times: %s", n, line)
+		}
+	}
+}
+
+func TestReplayDiscardsRejectedBatch(t *testing.T) {
+	server := newFakeInfluxDB(t)
+	server.buckets["REALTIME_cpu"] = 0
+	server.invalid = "broken"
+	config := &Config{URL: server.URL, Organization: testOrganization, BackupDir: t.TempDir()}
+
+	b := newBackup(config, pkg.DefaultLogger)
+	for _, batch := range []string{"broken line\n", "cpu value=2 2\n"} {
+		if err := b.append("REALTIME_cpu", batch); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	e, err := NewExporter(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer e.(*exporter).Close()
+	exp := e.(*exporter)
+	exp.record(context.Background())
+
+	// 거부된 batch는 버려지고 뒤의 batch는 전송된다.
+	if got := server.bucketLines("REALTIME_cpu"); len(got) != 1 || got[0] != "cpu value=2 2" {
+		t.Fatalf("lines = %v, want [cpu value=2 2]", got)
+	}
+	if got := counterValue(t, exp.self.discardedBatches, "REALTIME_cpu"); got != 1 {
+		t.Fatalf("discarded_batches = %v, want 1", got)
+	}
+	if got := counterValue(t, exp.self.droppedPoints, "REALTIME_cpu"); got != 1 {
+		t.Fatalf("dropped_points = %v, want 1", got)
+	}
+	if _, files := exp.backup.stats(); files != 0 {
+		t.Fatalf("backup files = %d, want 0", files)
+	}
+}
+
+func TestReplayKeepsBatchOnRetryableError(t *testing.T) {
+	server := newFakeInfluxDB(t)
+	server.buckets["REALTIME_cpu"] = 0
+	server.failWrites = 1
+	config := &Config{URL: server.URL, Organization: testOrganization, BackupDir: t.TempDir()}
+
+	b := newBackup(config, pkg.DefaultLogger)
+	if err := b.append("REALTIME_cpu", "cpu value=1 1\n"); err != nil {
+		t.Fatal(err)
+	}
+	e, err := NewExporter(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer e.(*exporter).Close()
+	exp := e.(*exporter)
+
+	exp.record(context.Background())
+	if got := server.bucketLines("REALTIME_cpu"); len(got) != 0 {
+		t.Fatalf("lines after 503 = %v, want none", got)
+	}
+	exp.record(context.Background())
+	if got := server.bucketLines("REALTIME_cpu"); len(got) != 1 || got[0] != "cpu value=1 1" {
+		t.Fatalf("lines = %v, want [cpu value=1 1]", got)
+	}
+	if got := counterValue(t, exp.self.discardedBatches, "REALTIME_cpu"); got != 0 {
+		t.Fatalf("discarded_batches = %v, want 0", got)
+	}
+}
+
+// queuedBatches는 bucketName의 큐에 남은 batch를 cursor를 옮기지 않고 반환한다.
+func queuedBatches(t *testing.T, b *backup, bucketName string) []string {
+	t.Helper()
+	b.mtx.Lock()
+	q := b.queues[bucketName]
+	b.mtx.Unlock()
+	if q == nil {
+		return nil
+	}
+
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+	var got []string
+	for _, seg := range q.segments {
+		for offset := int64(segmentHeaderSize); offset < seg.size; {
+			n, payload, err := readRecordAt(seg.path, offset)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, string(payload))
+			offset += n
+		}
+	}
+	return got
+}
+
+func TestBackupEvictsOldestSegmentsBySize(t *testing.T) {
+	// segment마다 record 하나(20 + 8 + 10 = 38 bytes)가 기록되므로 두 segment까지 유지된다.
+	b := newBackup(&Config{BackupDir: t.TempDir(), BackupMaxBytes: 100, BackupSegmentBytes: 1}, pkg.DefaultLogger)
+	evicted := map[string]int{}
+	b.onEvict = func(bucketName string, segments int) { evicted[bucketName] += segments }
+
+	for _, record := range []struct{ bucket, batch string }{
+		{"REALTIME_a", "a1 value=1"},
+		{"REALTIME_b", "b1 value=1"},
+		{"REALTIME_a", "a2 value=1"},
+	} {
+		if err := b.append(record.bucket, record.batch); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if !reflect.DeepEqual(evicted, map[string]int{"REALTIME_a": 1}) {
+		t.Fatalf("evicted = %v, want the oldest segment of REALTIME_a", evicted)
+	}
+	if got := queuedBatches(t, b, "REALTIME_a"); !reflect.DeepEqual(got, []string{"a2 value=1"}) {
+		t.Fatalf("REALTIME_a = %v", got)
+	}
+	if got := queuedBatches(t, b, "REALTIME_b"); !reflect.DeepEqual(got, []string{"b1 value=1"}) {
+		t.Fatalf("REALTIME_b = %v", got)
+	}
+	if bytes, files := b.stats(); bytes > 100 || files != 2 {
+		t.Fatalf("stats = %d bytes, %d files", bytes, files)
+	}
+}
+
+func TestBackupExpiresSegmentsByAge(t *testing.T) {
+	b := newBackup(&Config{BackupDir: t.TempDir(), BackupMaxAgeSeconds: 60, BackupSegmentBytes: 1}, pkg.DefaultLogger)
+	evicted := map[string]int{}
+	b.onEvict = func(bucketName string, segments int) { evicted[bucketName] += segments }
+
+	for _, batch := range []string{"a1 value=1", "a2 value=1"} {
+		if err := b.append("REALTIME_a", batch); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := b.append("REALTIME_b", "b1 value=1"); err != nil {
+		t.Fatal(err)
+	}
+
+	// REALTIME_a의 첫 segment만 보관 기간을 넘긴 것으로 만든다.
+	b.mtx.Lock()
+	b.queues["REALTIME_a"].segments[0].modTime = time.Now().Add(-2 * time.Minute)
+	b.enforce(time.Now())
+	b.mtx.Unlock()
+
+	if !reflect.DeepEqual(evicted, map[string]int{"REALTIME_a": 1}) {
+		t.Fatalf("evicted = %v, want one expired segment of REALTIME_a", evicted)
+	}
+	if got := queuedBatches(t, b, "REALTIME_a"); !reflect.DeepEqual(got, []string{"a2 value=1"}) {
+		t.Fatalf("REALTIME_a = %v", got)
+	}
+	if got := queuedBatches(t, b, "REALTIME_b"); !reflect.DeepEqual(got, []string{"b1 value=1"}) {
+		t.Fatalf("REALTIME_b = %v", got)
+	}
+}
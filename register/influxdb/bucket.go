@@ -5,7 +5,6 @@ import (
 	"strings"
 	"time"
 
-	"github.com/influxdata/influxdb-client-go/v2/api/write"
 	"github.com/winey-dev/telemetry/dto"
 	"github.com/winey-dev/telemetry/metric"
 )
@@ -15,67 +14,95 @@ const (
 )
 
 type Bucket struct {
-	items map[string][]*write.Point // Map of Period + Category to Metrics
+	precision time.Duration
+	mapping   *mapping
+	items     map[string]*Encoder // Map of bucket name(기본값 Period + Category) to encoded lines
+	periods   map[string]string   // Map of bucket name to period
 }
 
 func NewBucket() *Bucket {
+	return NewBucketWithPrecision(time.Nanosecond)
+}
+
+// NewBucketWithPrecision은 precision 단위의 timestamp로 인코딩하는 Bucket을 생성한다.
+func NewBucketWithPrecision(precision time.Duration) *Bucket {
 	return &Bucket{
-		items: make(map[string][]*write.Point),
+		precision: precision,
+		mapping:   defaultMapping,
+		items:     make(map[string]*Encoder),
+		periods:   make(map[string]string),
 	}
 }
 
-func (b *Bucket) Add(metric metric.Metric, now time.Time) {
-	var value dto.Metric
-	metric.Write(&value)
+// NewBucketWithMapping은 mapping에 따라 bucket, measurement, 태그, 필드를 결정하는 Bucket을 생성한다.
+// mapping의 template이 잘못되었으면 ErrInvalidTemplate을 반환한다.
+func NewBucketWithMapping(precision time.Duration, mapping *Mapping) (*Bucket, error) {
+	mp, err := newMapping(mapping)
+	if err != nil {
+		return nil, err
+	}
+	return newBucket(precision, mp), nil
+}
 
-	key := strings.Join([]string{period, value.Category}, "_")
+func newBucket(precision time.Duration, mp *mapping) *Bucket {
+	b := NewBucketWithPrecision(precision)
+	b.mapping = mp
+	return b
+}
 
-	point := toWritePoint(&value, now)
-	b.items[key] = append(b.items[key], point)
+func (b *Bucket) Add(metric metric.Metric, now time.Time) error {
+	var value dto.Metric
+	if err := metric.Write(&value); err != nil {
+		return err
+	}
+	return b.AddValue(&value, now)
 }
 
-func toWritePoint(m *dto.Metric, now time.Time) *write.Point {
-	tags := map[string]string{}
-	fields := map[string]interface{}{}
+// AddValue는 이미 기록된 metric 값을 추가한다.
+func (b *Bucket) AddValue(value *dto.Metric, now time.Time) error {
+	return b.addPeriod(period, value, now)
+}
 
-	tags["item_name"] = m.ItemName
-	for i, tagName := range m.TagNames {
-		tags[tagName] = m.TagValues[i]
+// addPeriod는 period에 해당하는 bucket(기본값 <period>_<category>)에 값을 추가한다.
+func (b *Bucket) addPeriod(period string, value *dto.Metric, now time.Time) error {
+	key := b.mapping.bucket(period, value)
+	if key == "" {
+		return ErrEmptyBucket
 	}
-	fields["value"] = m.Value
 
-	point := write.NewPoint(
-		m.SubCategory,
-		tags,
-		fields,
-		now,
-	)
-	return point
+	encoder, ok := b.items[key]
+	if !ok {
+		encoder = NewEncoder(b.precision)
+		b.items[key] = encoder
+		b.periods[key] = period
+	}
+	return encoder.encodeMapped(b.mapping, period, value, now)
 }
 
-func (b *Bucket) Summary(now time.Time) {
-	var builder strings.Builder
-
-	builder.WriteString("InfluxDB Bucket Summary:\n")
-	builder.WriteString(fmt.Sprintf("- Time: %s\n", now.Format(time.RFC3339)))
-	for key, points := range b.items {
-		builder.WriteString(fmt.Sprintf("- Bucket: %s, Points: %d\n", key, len(points)))
-		for _, point := range points {
-			builder.WriteString(fmt.Sprintf("  - Point: %s\n", pointToString(point)))
+// lineProtocol은 bucket 별 line protocol batch를 반환한다.
+func (b *Bucket) lineProtocol() map[string]string {
+	batches := make(map[string]string, len(b.items))
+	for key, encoder := range b.items {
+		if encoder.Len() > 0 {
+			batches[key] = string(encoder.Bytes())
 		}
 	}
-	fmt.Println(builder.String())
+	return batches
 }
 
-func pointToString(point *write.Point) string {
+// Summary는 bucket 별 point 수와 line을 사람이 읽을 수 있는 문자열로 반환한다. 디버깅에 사용한다.
+func (b *Bucket) Summary(now time.Time) string {
 	var builder strings.Builder
-	builder.WriteString(fmt.Sprintf("Measurement: %s, ", point.Name()))
-	for _, tag := range point.TagList() {
-		builder.WriteString(fmt.Sprintf("%s=%s, ", tag.Key, tag.Value))
-	}
-	for _, field := range point.FieldList() {
-		builder.WriteString(fmt.Sprintf("%s=%v, ", field.Key, field.Value))
+
+	builder.WriteString("InfluxDB Bucket Summary:\n")
+	builder.WriteString(fmt.Sprintf("- Time: %s\n", now.Format(time.RFC3339)))
+	for key, encoder := range b.items {
+		builder.WriteString(fmt.Sprintf("- Bucket: %s, Points: %d\n", key, encoder.Len()))
+		for _, line := range strings.SplitAfter(string(encoder.Bytes()), "\n") {
+			if line != "" {
+				builder.WriteString(fmt.Sprintf("  - Point: %s", line))
+			}
+		}
 	}
-	builder.WriteString(fmt.Sprintf("Time: %s", point.Time().Format(time.RFC3339)))
 	return builder.String()
 }
@@ -1,5 +1,12 @@
 package influxdb
 
+import (
+	"fmt"
+	"time"
+)
+
+const defaultReplayInterval = time.Minute
+
 type Config struct {
 	URL             string
 	Token           string
@@ -8,4 +15,66 @@ type Config struct {
 	ClearValue      bool
 	RetryAttempts   int
 	BackupDir       string
+
+	// MaxSeries는 등록된 MetricVec 전체의 series 수 제한이다. 0이면 제한하지 않는다.
+	MaxSeries int
+
+	// BackupMaxBytes는 BackupDir에 보관할 수 있는 전체 크기이다. 0이면 제한하지 않는다.
+	BackupMaxBytes int64
+	// BackupMaxAgeSeconds보다 오래된 백업 세그먼트는 삭제된다. 0이면 제한하지 않는다.
+	BackupMaxAgeSeconds int
+	// BackupSegmentBytes는 세그먼트 파일 하나의 최대 크기이다. 0이면 4MiB를 사용한다.
+	BackupSegmentBytes int64
+
+	// Precision은 line protocol timestamp의 단위이다(time.Nanosecond, Microsecond, Millisecond, Second).
+	// 0이면 time.Nanosecond를 사용한다. 백업된 batch는 기록 당시의 단위로 재전송되므로 운영 중 변경하지 않는다.
+	Precision time.Duration
+
+	// Rollup이 true이면 REALTIME 값을 5MIN, HOUR, DAY 구간으로 집계하여 <주기>_<category> bucket에 기록한다.
+	Rollup bool
+	// RollupStateFile은 집계 중인 값을 저장하는 파일이다. 구간(5MIN)이 끝날 때와 agent를 중지할 때 저장하므로
+	// 비정상 종료 시 마지막 저장 이후의 값은 사라진다. 비어 있으면 재시작 시 집계 중인 값이 사라진다.
+	RollupStateFile string
+
+	// Mapping은 metric을 bucket, measurement, 태그, 필드로 변환하는 규칙이다. nil이면 기본 규칙을 사용한다.
+	Mapping *Mapping
+
+	// Provision이 true이면 Start 시점과 새로운 bucket에 처음 기록할 때 bucket이 없으면 생성한다.
+	Provision bool
+	// Retention은 생성하는 bucket의 보관 기간이다. bucket 이름, period(REALTIME, 5MIN, HOUR, DAY) 순서로 찾으며
+	// 없으면 무기한 보관한다.
+	//
+	//	Retention: map[string]time.Duration{
+	//		"REALTIME":     7 * 24 * time.Hour,
+	//		"REALTIME_cpu": 24 * time.Hour,
+	//		"DAY":          0,
+	//	}
+	Retention map[string]time.Duration
+}
+
+func (c *Config) precision() time.Duration {
+	if c.Precision == 0 {
+		return time.Nanosecond
+	}
+	return c.Precision
+}
+
+// replayInterval은 backup 재전송 주기이다. IntervalSeconds가 설정되지 않았으면 defaultReplayInterval을 사용한다.
+func (c *Config) replayInterval() time.Duration {
+	if c.IntervalSeconds <= 0 {
+		return defaultReplayInterval
+	}
+	return time.Duration(c.IntervalSeconds) * time.Second
+}
+
+// validate는 Config를 검사하고 Mapping의 template을 해석한 결과를 반환한다.
+func (c *Config) validate() (*mapping, error) {
+	if !validPrecision(c.precision()) {
+		return nil, ErrInvalidPrecision
+	}
+	mapping, err := newMapping(c.Mapping)
+	if err != nil {
+		return nil, fmt.Errorf("mapping: %w", err)
+	}
+	return mapping, nil
 }
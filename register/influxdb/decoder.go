@@ -0,0 +1,244 @@
+package influxdb
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/winey-dev/telemetry/dto"
+)
+
+// Decode는 Encoder가 기본 규칙(Encode)으로 기록한 line 하나를 dto.Metric으로 되돌린다.
+// item_name 태그는 ItemName으로, value 필드는 ValueType에 맞는 단일 값으로, 그 외의 필드는 Fields로 읽는다.
+// Category, Description, Kind는 line에 기록되지 않으므로 비어 있고, 태그는 key 순서이다.
+// timestamp는 precision 단위로 해석하며 0이면 time.Nanosecond를 사용한다.
+func Decode(line []byte, precision time.Duration) (string, *dto.Metric, time.Time, error) {
+	if precision == 0 {
+		precision = time.Nanosecond
+	}
+	if !validPrecision(precision) {
+		return "", nil, time.Time{}, ErrInvalidPrecision
+	}
+	line = bytes.TrimSuffix(line, []byte{'\n'})
+
+	d := decoder{line: line}
+	measurement := d.token(measurementEscapes, ", ")
+	if measurement == "" {
+		return "", nil, time.Time{}, ErrEmptyMeasurement
+	}
+
+	m := &dto.Metric{}
+	for d.next(',') {
+		key := d.token(tagEscapes, "=")
+		if !d.next('=') || key == "" {
+			return "", nil, time.Time{}, d.errorf("tag key")
+		}
+		value := d.token(tagEscapes, ", ")
+		if value == "" {
+			return "", nil, time.Time{}, d.errorf("tag value")
+		}
+		if key == itemNameTag {
+			m.ItemName = value
+			continue
+		}
+		m.TagNames = append(m.TagNames, key)
+		m.TagValues = append(m.TagValues, value)
+	}
+
+	if !d.next(' ') {
+		return "", nil, time.Time{}, d.errorf("fields")
+	}
+	var fields []dto.Metric
+	var keys []string
+	for {
+		key := d.token(tagEscapes, "=")
+		if !d.next('=') || key == "" {
+			return "", nil, time.Time{}, d.errorf("field key")
+		}
+		var field dto.Metric
+		if err := d.field(&field); err != nil {
+			return "", nil, time.Time{}, err
+		}
+		keys = append(keys, key)
+		fields = append(fields, field)
+		if !d.next(',') {
+			break
+		}
+	}
+	if err := setFields(m, keys, fields); err != nil {
+		return "", nil, time.Time{}, d.errorf(err.Error())
+	}
+
+	if !d.next(' ') {
+		return "", nil, time.Time{}, d.errorf("timestamp")
+	}
+	ts, err := strconv.ParseInt(string(d.line[d.pos:]), 10, 64)
+	if err != nil {
+		return "", nil, time.Time{}, d.errorf("timestamp")
+	}
+	switch precision {
+	case time.Second:
+		return measurement, m, time.Unix(ts, 0), nil
+	case time.Millisecond:
+		return measurement, m, time.UnixMilli(ts), nil
+	case time.Microsecond:
+		return measurement, m, time.UnixMicro(ts), nil
+	default:
+		return measurement, m, time.Unix(0, ts), nil
+	}
+}
+
+// setFields는 value 필드 하나만 있으면 단일 값으로, 그 외에는 숫자 필드를 Fields로 기록한다.
+func setFields(m *dto.Metric, keys []string, fields []dto.Metric) error {
+	if len(keys) == 1 && keys[0] == "value" {
+		field := fields[0]
+		m.ValueType = field.ValueType
+		m.Value = field.Value
+		m.IntValue = field.IntValue
+		m.UintValue = field.UintValue
+		m.BoolValue = field.BoolValue
+		m.StringValue = field.StringValue
+		return nil
+	}
+	for i, field := range fields {
+		var value float64
+		switch field.ValueType {
+		case dto.ValueFloat:
+			value = field.Value
+		case dto.ValueInt:
+			value = float64(field.IntValue)
+		case dto.ValueUint:
+			value = float64(field.UintValue)
+		default:
+			return fmt.Errorf("non-numeric field %s", keys[i])
+		}
+		m.Fields = append(m.Fields, dto.Field{Name: keys[i], Value: value})
+	}
+	return nil
+}
+
+type decoder struct {
+	line []byte
+	pos  int
+}
+
+func (d *decoder) errorf(part string) error {
+	return fmt.Errorf("%w: %s at %d: %q", ErrInvalidLine, part, d.pos, d.line)
+}
+
+// next는 다음 문자가 c이면 건너뛰고 true를 반환한다.
+func (d *decoder) next(c byte) bool {
+	if d.pos < len(d.line) && d.line[d.pos] == c {
+		d.pos++
+		return true
+	}
+	return false
+}
+
+// token은 escape되지 않은 stops 문자 전까지 읽고 escapes 문자의 escape를 해제한다.
+// backslash는 항상 다음 문자와 함께 읽으며, escapes에 없는 문자 앞의 backslash는 그대로 둔다.
+func (d *decoder) token(escapes, stops string) string {
+	start := d.pos
+	escaped := false
+	for d.pos < len(d.line) {
+		c := d.line[d.pos]
+		if c == '\\' && d.pos+1 < len(d.line) {
+			escaped = true
+			d.pos += 2
+			continue
+		}
+		if strings.IndexByte(stops, c) >= 0 {
+			break
+		}
+		d.pos++
+	}
+	raw := d.line[start:d.pos]
+	if !escaped {
+		return string(raw)
+	}
+
+	out := make([]byte, 0, len(raw))
+	for i := 0; i < len(raw); i++ {
+		if raw[i] == '\\' && i+1 < len(raw) {
+			if strings.IndexByte(escapes, raw[i+1]) >= 0 {
+				i++
+			} else {
+				out = append(out, raw[i], raw[i+1])
+				i++
+				continue
+			}
+		}
+		out = append(out, raw[i])
+	}
+	return string(out)
+}
+
+// field는 필드 값 하나를 읽어 out의 ValueType에 맞는 값으로 기록한다.
+func (d *decoder) field(out *dto.Metric) error {
+	if d.pos < len(d.line) && d.line[d.pos] == '"' {
+		value, ok := d.quoted()
+		if !ok {
+			return d.errorf("string field")
+		}
+		out.ValueType, out.StringValue = dto.ValueString, value
+		return nil
+	}
+
+	raw := d.line[d.pos:]
+	if i := bytes.IndexAny(raw, ", "); i >= 0 {
+		raw = raw[:i]
+	}
+	d.pos += len(raw)
+	if len(raw) == 0 {
+		return d.errorf("field value")
+	}
+
+	var err error
+	value := string(raw)
+	switch value {
+	case "t", "T", "true", "True", "TRUE":
+		out.ValueType, out.BoolValue = dto.ValueBool, true
+		return nil
+	case "f", "F", "false", "False", "FALSE":
+		out.ValueType, out.BoolValue = dto.ValueBool, false
+		return nil
+	}
+	switch value[len(value)-1] {
+	case 'i':
+		out.ValueType = dto.ValueInt
+		out.IntValue, err = strconv.ParseInt(value[:len(value)-1], 10, 64)
+	case 'u':
+		out.ValueType = dto.ValueUint
+		out.UintValue, err = strconv.ParseUint(value[:len(value)-1], 10, 64)
+	default:
+		out.ValueType = dto.ValueFloat
+		out.Value, err = strconv.ParseFloat(value, 64)
+	}
+	if err != nil {
+		return d.errorf("field value")
+	}
+	return nil
+}
+
+// quoted는 따옴표로 감싼 문자열 필드 값을 읽고 \", \\의 escape를 해제한다.
+func (d *decoder) quoted() (string, bool) {
+	d.pos++
+	var out []byte
+	for d.pos < len(d.line) {
+		c := d.line[d.pos]
+		switch {
+		case c == '"':
+			d.pos++
+			return string(out), true
+		case c == '\\' && d.pos+1 < len(d.line) && (d.line[d.pos+1] == '"' || d.line[d.pos+1] == '\\'):
+			out = append(out, d.line[d.pos+1])
+			d.pos += 2
+			continue
+		}
+		out = append(out, c)
+		d.pos++
+	}
+	return "", false
+}
@@ -0,0 +1,326 @@
+package influxdb
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/winey-dev/telemetry/dto"
+)
+
+const itemNameTag = "item_name"
+
+// Encoder는 dto.Metric을 InfluxDB line protocol로 직렬화하여 내부 버퍼에 누적한다.
+// 태그는 key 순서로 정렬되며 빈 값을 가진 태그는 생략된다.
+// NaN, Inf 처럼 line protocol로 표현할 수 없는 float 필드와 이름이 빈 필드는 생략된다.
+// 개행이 포함되거나 구분자 앞에 홀수 개의 backslash가 있는 이름과 값은 line protocol로 표현할 수 없으므로
+// ErrInvalidCharacter를 반환한다. 기록한 line은 Decode로 되돌릴 수 있다.
+// Encoder는 동시에 사용할 수 없다.
+type Encoder struct {
+	precision time.Duration
+	buf       []byte
+	lines     int
+	fields    int
+	tags      []tag
+	err       error
+}
+
+type tag struct {
+	key   string
+	value string
+}
+
+// NewEncoder는 precision(time.Nanosecond, Microsecond, Millisecond, Second) 단위로 timestamp를 기록하는 Encoder를 생성한다.
+// 0이면 time.Nanosecond를 사용한다.
+func NewEncoder(precision time.Duration) *Encoder {
+	if precision == 0 {
+		precision = time.Nanosecond
+	}
+	if !validPrecision(precision) {
+		panic(ErrInvalidPrecision.Error())
+	}
+	return &Encoder{precision: precision}
+}
+
+func validPrecision(precision time.Duration) bool {
+	switch precision {
+	case time.Nanosecond, time.Microsecond, time.Millisecond, time.Second:
+		return true
+	}
+	return false
+}
+
+// Bytes는 지금까지 인코딩한 line protocol을 반환한다. 다음 Encode 또는 Reset 전까지만 유효하다.
+func (e *Encoder) Bytes() []byte { return e.buf }
+
+// Len은 인코딩한 line 수를 반환한다.
+func (e *Encoder) Len() int { return e.lines }
+
+// Reset은 버퍼를 비우고 재사용한다.
+func (e *Encoder) Reset() {
+	e.buf = e.buf[:0]
+	e.lines = 0
+}
+
+// Encode는 m을 measurement 이름의 line 하나로 기록한다. m.ItemName은 item_name 태그로, 단일 값은 value 필드로 기록된다.
+// 오류가 발생하면 버퍼는 변경되지 않는다.
+func (e *Encoder) Encode(measurement string, m *dto.Metric, now time.Time) error {
+	return e.encode(defaultMapping, measurement, "value", m, now)
+}
+
+// encodeMapped는 mapping에 따라 m을 기록한다.
+func (e *Encoder) encodeMapped(mp *mapping, period string, m *dto.Metric, now time.Time) error {
+	return e.encode(mp, mp.measurement(period, m), mp.field(period, m), m, now)
+}
+
+func (e *Encoder) encode(mp *mapping, measurement, valueField string, m *dto.Metric, now time.Time) error {
+	if measurement == "" {
+		return ErrEmptyMeasurement
+	}
+	start := len(e.buf)
+	e.err = nil
+
+	e.appendEscaped(measurement, measurementEscapes)
+	e.collectTags(mp, m)
+	for _, t := range e.tags {
+		e.buf = append(e.buf, ',')
+		e.appendEscaped(t.key, tagEscapes)
+		e.buf = append(e.buf, '=')
+		e.appendEscaped(t.value, tagEscapes)
+	}
+
+	e.buf = append(e.buf, ' ')
+	e.fields = 0
+	e.appendFields(m, valueField)
+	if e.err != nil {
+		e.buf = e.buf[:start]
+		return e.err
+	}
+	if e.fields == 0 {
+		e.buf = e.buf[:start]
+		return ErrNoFields
+	}
+
+	e.buf = append(e.buf, ' ')
+	e.buf = e.appendTimestamp(e.buf, now)
+	e.buf = append(e.buf, '\n')
+	e.lines++
+	return nil
+}
+
+// collectTags는 m의 태그, ItemName, Category, 전역 태그를 key 순서로 e.tags에 채운다.
+// 이름이 같은 태그는 앞의 순서(m의 태그가 가장 우선)를 따른다.
+func (e *Encoder) collectTags(mp *mapping, m *dto.Metric) {
+	e.tags = e.tags[:0]
+	for i, key := range m.TagNames {
+		if key == "" || i >= len(m.TagValues) || m.TagValues[i] == "" {
+			continue
+		}
+		e.tags = append(e.tags, tag{key: key, value: m.TagValues[i]})
+	}
+	if mp.itemNameTag != "" && m.ItemName != "" {
+		e.tags = append(e.tags, tag{key: mp.itemNameTag, value: m.ItemName})
+	}
+	if mp.categoryTag != "" && m.Category != "" {
+		e.tags = append(e.tags, tag{key: mp.categoryTag, value: m.Category})
+	}
+	e.tags = append(e.tags, mp.tags...)
+
+	sortTags(e.tags)
+	n := 0
+	for i, t := range e.tags {
+		if i > 0 && t.key == e.tags[n-1].key {
+			continue
+		}
+		e.tags[n] = t
+		n++
+	}
+	e.tags = e.tags[:n]
+}
+
+// sortTags는 태그를 key 순서로 정렬한다. 태그 수가 적으므로 삽입 정렬을 사용하며 같은 key의 순서는 유지된다.
+func sortTags(tags []tag) {
+	for i := 1; i < len(tags); i++ {
+		for j := i; j > 0 && tags[j].key < tags[j-1].key; j-- {
+			tags[j], tags[j-1] = tags[j-1], tags[j]
+		}
+	}
+}
+
+func (e *Encoder) appendFields(m *dto.Metric, valueField string) {
+	switch {
+	case m.Histogram != nil:
+		// 버킷별 누적 개수를 le_<상한> 필드로, sum과 count를 각각의 필드로 기록한다.
+		for _, bucket := range m.Histogram.Buckets {
+			e.appendFieldKey("le_", bucket.UpperBound, 'g')
+			e.buf = appendUint(e.buf, bucket.Count)
+		}
+		e.appendUintField("le_+Inf", m.Histogram.Count)
+		e.appendFloatField("sum", m.Histogram.Sum)
+		e.appendUintField("count", m.Histogram.Count)
+	case m.Summary != nil:
+		// 분위수를 p<백분위> 필드(예: p50, p99.9)로 기록한다.
+		for _, q := range m.Summary.Quantiles {
+			if !finite(q.Value) {
+				continue
+			}
+			e.appendFieldKey("p", quantilePercent(q.Quantile), 'f')
+			e.buf = strconv.AppendFloat(e.buf, q.Value, 'g', -1, 64)
+		}
+		e.appendFloatField("sum", m.Summary.Sum)
+		e.appendUintField("count", m.Summary.Count)
+	case m.Aggregation != nil:
+		// 관측 값이 없으면 count와 sum만 기록한다.
+		if m.Aggregation.Count > 0 {
+			e.appendFloatField("mean", m.Aggregation.Mean)
+			e.appendFloatField("min", m.Aggregation.Min)
+			e.appendFloatField("max", m.Aggregation.Max)
+		}
+		e.appendFloatField("sum", m.Aggregation.Sum)
+		e.appendUintField("count", m.Aggregation.Count)
+	case len(m.Fields) > 0:
+		for _, field := range m.Fields {
+			e.appendFloatField(field.Name, field.Value)
+		}
+	default:
+		e.appendValueField(valueField, m)
+	}
+}
+
+func (e *Encoder) appendValueField(key string, m *dto.Metric) {
+	switch m.ValueType {
+	case dto.ValueInt:
+		if e.beginField(key) {
+			e.buf = strconv.AppendInt(e.buf, m.IntValue, 10)
+			e.buf = append(e.buf, 'i')
+		}
+	case dto.ValueUint:
+		e.appendUintField(key, m.UintValue)
+	case dto.ValueBool:
+		if e.beginField(key) {
+			e.buf = strconv.AppendBool(e.buf, m.BoolValue)
+		}
+	case dto.ValueString:
+		if e.beginField(key) {
+			e.appendQuoted(m.StringValue)
+		}
+	default:
+		e.appendFloatField(key, m.Value)
+	}
+}
+
+// beginField는 필드 구분자와 key=를 기록한다. key가 비어 있으면 기록하지 않고 false를 반환한다.
+func (e *Encoder) beginField(key string) bool {
+	if key == "" {
+		return false
+	}
+	if e.fields > 0 {
+		e.buf = append(e.buf, ',')
+	}
+	e.fields++
+	e.appendEscaped(key, tagEscapes)
+	e.buf = append(e.buf, '=')
+	return true
+}
+
+// quantilePercent는 분위수를 필드 이름에 사용할 백분위로 바꾼다.
+// 0.07*100이 7.000000000000001이 되는 것처럼 부동소수점 오차가 이름에 남지 않도록 소수점 넷째 자리에서 반올림한다.
+func quantilePercent(q float64) float64 {
+	return math.Round(q*1e6) / 1e4
+}
+
+// appendFieldKey는 prefix 뒤에 숫자를 붙인 필드 key를 할당 없이 기록한다.
+func (e *Encoder) appendFieldKey(prefix string, n float64, format byte) {
+	if e.fields > 0 {
+		e.buf = append(e.buf, ',')
+	}
+	e.fields++
+	e.buf = append(e.buf, prefix...)
+	e.buf = strconv.AppendFloat(e.buf, n, format, -1, 64)
+	e.buf = append(e.buf, '=')
+}
+
+func (e *Encoder) appendFloatField(key string, v float64) {
+	if finite(v) && e.beginField(key) {
+		e.buf = strconv.AppendFloat(e.buf, v, 'g', -1, 64)
+	}
+}
+
+func (e *Encoder) appendUintField(key string, v uint64) {
+	if e.beginField(key) {
+		e.buf = appendUint(e.buf, v)
+	}
+}
+
+func (e *Encoder) appendTimestamp(b []byte, now time.Time) []byte {
+	switch e.precision {
+	case time.Second:
+		return strconv.AppendInt(b, now.Unix(), 10)
+	case time.Millisecond:
+		return strconv.AppendInt(b, now.UnixMilli(), 10)
+	case time.Microsecond:
+		return strconv.AppendInt(b, now.UnixMicro(), 10)
+	default:
+		return strconv.AppendInt(b, now.UnixNano(), 10)
+	}
+}
+
+func appendUint(b []byte, v uint64) []byte {
+	b = strconv.AppendUint(b, v, 10)
+	return append(b, 'u')
+}
+
+func finite(v float64) bool {
+	return !math.IsNaN(v) && !math.IsInf(v, 0)
+}
+
+const (
+	// measurement는 ,와 공백을, 태그 key와 값, 필드 key는 ,와 =, 공백을 escape한다.
+	measurementEscapes = ", "
+	tagEscapes         = ",= "
+)
+
+// appendEscaped는 s의 escapes에 포함된 문자 앞에 backslash를 붙여 기록한다.
+// line protocol은 개행을 표현할 수 없고, 홀수 개의 backslash 뒤에 escape 대상 문자나 구분자가 오면
+// backslash가 그 문자를 escape하는 것으로 해석되므로 이 경우 e.err에 ErrInvalidCharacter를 기록한다.
+func (e *Encoder) appendEscaped(s, escapes string) {
+	backslashes := 0
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '\n' || c == '\r':
+			e.err = ErrInvalidCharacter
+		case strings.IndexByte(escapes, c) >= 0:
+			if backslashes%2 == 1 {
+				e.err = ErrInvalidCharacter
+			}
+			e.buf = append(e.buf, '\\')
+		}
+		if c == '\\' {
+			backslashes++
+		} else {
+			backslashes = 0
+		}
+		e.buf = append(e.buf, c)
+	}
+	if backslashes%2 == 1 {
+		e.err = ErrInvalidCharacter
+	}
+}
+
+// appendQuoted는 문자열 필드 값을 따옴표로 감싸고 ", \를 escape하여 기록한다.
+func (e *Encoder) appendQuoted(s string) {
+	e.buf = append(e.buf, '"')
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch c {
+		case '"', '\\':
+			e.buf = append(e.buf, '\\')
+		case '\n', '\r':
+			e.err = ErrInvalidCharacter
+		}
+		e.buf = append(e.buf, c)
+	}
+	e.buf = append(e.buf, '"')
+}
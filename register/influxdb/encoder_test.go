@@ -0,0 +1,277 @@
+package influxdb
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/winey-dev/telemetry/dto"
+)
+
+func TestEncodeEscaping(t *testing.T) {
+	ts := time.Unix(0, 1700000000000000000)
+	tests := []struct {
+		name        string
+		measurement string
+		metric      dto.Metric
+		want        string
+	}{
+		{
+			name:        "measurement escapes comma and space only",
+			measurement: "cpu load,a=b",
+			metric:      dto.Metric{Value: 1},
+			want:        `cpu\ load\,a=b value=1 1700000000000000000` + "\n",
+		},
+		{
+			name:        "tag key and value escape comma, equals and space",
+			measurement: "m",
+			metric:      dto.Metric{ItemName: "i", TagNames: []string{"a b", "c=d"}, TagValues: []string{"x,y", "p q=r"}, Value: 1},
+			want:        `m,a\ b=x\,y,c\=d=p\ q\=r,item_name=i value=1 1700000000000000000` + "\n",
+		},
+		{
+			name:        "backslash is kept as is",
+			measurement: `a\b`,
+			metric:      dto.Metric{TagNames: []string{"k"}, TagValues: []string{`c:\tmp\\`}, Value: 1},
+			want:        `a\b,k=c:\tmp\\ value=1 1700000000000000000` + "\n",
+		},
+		{
+			name:        "string field escapes quote and backslash",
+			measurement: "m",
+			metric:      dto.Metric{ValueType: dto.ValueString, StringValue: `say "hi" \o/`},
+			want:        `m value="say \"hi\" \\o/" 1700000000000000000` + "\n",
+		},
+		{
+			name:        "typed values",
+			measurement: "m",
+			metric:      dto.Metric{ValueType: dto.ValueInt, IntValue: -3},
+			want:        "m value=-3i 1700000000000000000\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := NewEncoder(0)
+			if err := e.Encode(tt.measurement, &tt.metric, ts); err != nil {
+				t.Fatal(err)
+			}
+			if got := string(e.Bytes()); got != tt.want {
+				t.Fatalf("got  %q\nwant %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncodeRejectsUnrepresentable(t *testing.T) {
+	tests := []struct {
+		name        string
+		measurement string
+		metric      dto.Metric
+	}{
+		{"newline in measurement", "a\nb", dto.Metric{Value: 1}},
+		{"carriage return in tag value", "m", dto.Metric{TagNames: []string{"k"}, TagValues: []string{"a\rb"}, Value: 1}},
+		{"newline in string field", "m", dto.Metric{ValueType: dto.ValueString, StringValue: "a\nb"}},
+		{"trailing backslash in tag value", "m", dto.Metric{TagNames: []string{"k"}, TagValues: []string{`a\`}, Value: 1}},
+		{"backslash before comma in tag value", "m", dto.Metric{TagNames: []string{"k"}, TagValues: []string{`a\,b`}, Value: 1}},
+		{"trailing backslash in measurement", `m\`, dto.Metric{Value: 1}},
+		{"newline in field name", "m", dto.Metric{Fields: []dto.Field{{Name: "a\nb", Value: 1}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := NewEncoder(0)
+			if err := e.Encode(tt.measurement, &tt.metric, time.Now()); !errors.Is(err, ErrInvalidCharacter) {
+				t.Fatalf("err = %v, want ErrInvalidCharacter", err)
+			}
+			if len(e.Bytes()) != 0 || e.Len() != 0 {
+				t.Fatalf("buffer was modified: %q", e.Bytes())
+			}
+		})
+	}
+}
+
+func TestDecodePrecision(t *testing.T) {
+	now := time.Unix(1700000000, 123456789)
+	for _, precision := range []time.Duration{time.Nanosecond, time.Microsecond, time.Millisecond, time.Second} {
+		e := NewEncoder(precision)
+		if err := e.Encode("m", &dto.Metric{Value: 1}, now); err != nil {
+			t.Fatal(err)
+		}
+		_, _, ts, err := Decode(e.Bytes(), precision)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := now.Truncate(precision); !ts.Equal(want) {
+			t.Errorf("precision %s: timestamp = %s, want %s", precision, ts, want)
+		}
+	}
+}
+
+func TestDecodeFields(t *testing.T) {
+	e := NewEncoder(0)
+	m := &dto.Metric{ItemName: "mem", Fields: []dto.Field{{Name: "used", Value: 1.5}, {Name: "free space", Value: -2}}}
+	if err := e.Encode("host", m, time.Unix(0, 1)); err != nil {
+		t.Fatal(err)
+	}
+	_, got, _, err := Decode(e.Bytes(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, m) {
+		t.Fatalf("got %+v, want %+v", got, m)
+	}
+}
+
+func TestDecodeInvalidLine(t *testing.T) {
+	for _, line := range []string{
+		"",
+		"m",
+		"m,k value=1 1",
+		"m,k= value=1 1",
+		"m value= 1",
+		`m value="open 1`,
+		"m value=1",
+		"m value=1 now",
+		"m value=1x 1",
+		`m a=1,b="s" 1`,
+	} {
+		if _, _, _, err := Decode([]byte(line), 0); err == nil {
+			t.Errorf("Decode(%q) succeeded", line)
+		}
+	}
+}
+
+// FuzzEncode는 Encode로 기록한 line을 Decode하면 원래의 dto.Metric이 되는지 확인한다.
+// Encode가 오류를 반환한 입력은 line protocol로 표현할 수 없는 입력이어야 한다.
+func FuzzEncode(f *testing.F) {
+	f.Add("cpu", "usage", "host", "a b", "zone", "x,y=z", uint8(0), 1.5, int64(-1), uint64(1), true, `q"\`)
+	f.Add(`m\`, `i\\`, `k\`, `v\\,`, "", "", uint8(1), 0.0, int64(math.MinInt64), uint64(0), false, "")
+	f.Add("m e", "i=1", "k=", "=v", "item_name", "x", uint8(4), math.Inf(1), int64(0), uint64(math.MaxUint64), false, "a\nb")
+	f.Fuzz(func(t *testing.T, measurement, itemName, k1, v1, k2, v2 string, valueType uint8, value float64, intValue int64, uintValue uint64, boolValue bool, stringValue string) {
+		m := &dto.Metric{
+			ItemName:    itemName,
+			TagNames:    []string{k1, k2},
+			TagValues:   []string{v1, v2},
+			ValueType:   dto.ValueType(valueType % 5),
+			Value:       value,
+			IntValue:    intValue,
+			UintValue:   uintValue,
+			BoolValue:   boolValue,
+			StringValue: stringValue,
+		}
+		now := time.Unix(0, intValue)
+
+		e := NewEncoder(0)
+		err := e.Encode(measurement, m, now)
+		switch {
+		case errors.Is(err, ErrEmptyMeasurement):
+			if measurement != "" {
+				t.Fatalf("ErrEmptyMeasurement for %q", measurement)
+			}
+			return
+		case errors.Is(err, ErrNoFields):
+			if m.ValueType != dto.ValueFloat || finite(value) {
+				t.Fatalf("ErrNoFields for %+v", m)
+			}
+			return
+		case errors.Is(err, ErrInvalidCharacter):
+			if !strings.ContainsAny(measurement+itemName+k1+v1+k2+v2+stringValue, "\n\r\\") {
+				t.Fatalf("ErrInvalidCharacter for %+v", m)
+			}
+			return
+		case err != nil:
+			t.Fatal(err)
+		}
+
+		gotMeasurement, got, ts, err := Decode(e.Bytes(), 0)
+		if err != nil {
+			t.Fatalf("Decode(%q): %v", e.Bytes(), err)
+		}
+		if gotMeasurement != measurement {
+			t.Fatalf("measurement = %q, want %q", gotMeasurement, measurement)
+		}
+		if !ts.Equal(now) {
+			t.Fatalf("timestamp = %s, want %s", ts, now)
+		}
+		if want := expectedDecode(m); !reflect.DeepEqual(got, want) {
+			t.Fatalf("line %q\ngot  %+v\nwant %+v", e.Bytes(), got, want)
+		}
+	})
+}
+
+// expectedDecode는 line에 기록되는 정보만 남긴 m이다.
+// 빈 태그는 생략되고, 같은 key는 먼저 나온 값이 사용되며, item_name 태그는 ItemName보다 우선한다.
+func expectedDecode(m *dto.Metric) *dto.Metric {
+	want := &dto.Metric{ValueType: m.ValueType}
+	switch m.ValueType {
+	case dto.ValueInt:
+		want.IntValue = m.IntValue
+	case dto.ValueUint:
+		want.UintValue = m.UintValue
+	case dto.ValueBool:
+		want.BoolValue = m.BoolValue
+	case dto.ValueString:
+		want.StringValue = m.StringValue
+	default:
+		want.Value = m.Value
+	}
+
+	tags := make(map[string]string)
+	for i, key := range m.TagNames {
+		if _, ok := tags[key]; ok || key == "" || m.TagValues[i] == "" {
+			continue
+		}
+		tags[key] = m.TagValues[i]
+	}
+	if _, ok := tags[itemNameTag]; !ok && m.ItemName != "" {
+		tags[itemNameTag] = m.ItemName
+	}
+	want.ItemName = tags[itemNameTag]
+	delete(tags, itemNameTag)
+
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		want.TagNames = append(want.TagNames, key)
+		want.TagValues = append(want.TagValues, tags[key])
+	}
+	return want
+}
+
+func TestQuantileFieldNames(t *testing.T) {
+	tests := []struct {
+		quantile float64
+		want     string
+	}{
+		{0.07, "p7"},
+		{0.29, "p29"},
+		{0.5, "p50"},
+		{0.57, "p57"},
+		{0.58, "p58"},
+		{0.99, "p99"},
+		{0.999, "p99.9"},
+		{0.9999, "p99.99"},
+	}
+	for _, tt := range tests {
+		m := &dto.Metric{Summary: &dto.Summary{Quantiles: []dto.Quantile{{Quantile: tt.quantile, Value: 1}}}}
+
+		e := NewEncoder(0)
+		if err := e.Encode("m", m, time.Unix(0, 1)); err != nil {
+			t.Fatal(err)
+		}
+		if want := "m " + tt.want + "=1,sum=0,count=0u 1\n"; string(e.Bytes()) != want {
+			t.Errorf("quantile %v: encoded %q, want %q", tt.quantile, e.Bytes(), want)
+		}
+
+		// rollup은 REALTIME bucket과 같은 필드 이름으로 집계한다.
+		var names []string
+		numericFields(m, "value", func(name string, _ float64) { names = append(names, name) })
+		if len(names) == 0 || names[0] != tt.want {
+			t.Errorf("quantile %v: rollup fields %v, want %s first", tt.quantile, names, tt.want)
+		}
+	}
+}
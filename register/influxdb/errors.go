@@ -0,0 +1,13 @@
+package influxdb
+
+import "errors"
+
+var (
+	ErrInvalidPrecision = errors.New("precision must be one of ns, us, ms or s")
+	ErrEmptyMeasurement = errors.New("measurement must not be empty")
+	ErrEmptyBucket      = errors.New("bucket must not be empty")
+	ErrInvalidTemplate  = errors.New("template has an unknown or unterminated placeholder")
+	ErrNoFields         = errors.New("metric has no field that can be written")
+	ErrInvalidCharacter = errors.New("line protocol cannot represent a newline or a backslash before a delimiter")
+	ErrInvalidLine      = errors.New("invalid line protocol")
+)
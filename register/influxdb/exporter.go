@@ -0,0 +1,308 @@
+package influxdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	influxdb2 "github.com/influxdata/influxdb-client-go/v2"
+	influxhttp "github.com/influxdata/influxdb-client-go/v2/api/http"
+	"github.com/winey-dev/telemetry/dto"
+	"github.com/winey-dev/telemetry/pkg"
+	"github.com/winey-dev/telemetry/register"
+)
+
+// exporter는 snapshot을 bucket 별 line protocol batch로 만들어 InfluxDB에 기록한다.
+// 재시도 후에도 기록하지 못한 batch는 backup에 저장하고 Start에서 시작한 별도의 goroutine이 주기마다 재전송한다.
+type exporter struct {
+	client      influxdb2.Client
+	config      *Config
+	mapping     *mapping
+	backup      *backup
+	rollup      *rollup      // Rollup이 false이면 nil
+	provisioner *provisioner // Provision이 false이면 nil
+	self        *selfMetrics
+
+	// pending은 마지막 snapshot에서 아직 기록하지 못한 batch이다.
+	// 재시도 시 같은 batch를 다시 사용하여 이미 기록한 bucket을 중복 기록하지 않는다.
+	mtx     sync.Mutex
+	pending *pendingBatches
+
+	// ctx는 backup 재전송 goroutine의 context로 Close에서 취소된다.
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	logger pkg.Logger
+}
+
+type pendingBatches struct {
+	now     time.Time
+	batches map[string]string // bucket name -> line protocol
+}
+
+// NewRegisterer는 InfluxDB Exporter 하나를 가진 Agent를 생성한다.
+func NewRegisterer(config *Config) (register.Agent, error) {
+	exporter, err := NewExporter(config)
+	if err != nil {
+		return nil, err
+	}
+	return register.NewAgent(&register.AgentConfig{
+		IntervalSeconds: config.IntervalSeconds,
+		RetryAttempts:   config.RetryAttempts,
+		MaxSeries:       config.MaxSeries,
+	}, exporter)
+}
+
+// NewExporter는 register.NewAgent에 다른 Exporter와 함께 사용할 수 있는 InfluxDB Exporter를 생성한다.
+func NewExporter(config *Config) (register.Exporter, error) {
+	mapping, err := config.validate()
+	if err != nil {
+		return nil, err
+	}
+	options := influxdb2.DefaultOptions().SetPrecision(config.precision())
+	client := influxdb2.NewClientWithOptions(config.URL, config.Token, options)
+	if client == nil {
+		return nil, fmt.Errorf("failed to create InfluxDB client: %s", config.URL)
+	}
+
+	backup := newBackup(config, pkg.DefaultLogger)
+	self := newSelfMetrics(backup)
+	backup.onEvict = func(bucketName string, segments int) {
+		self.evictedSegments.WithTagValues(bucketName).Add(float64(segments))
+	}
+	backup.onCorrupt = func(bucketName string, _ int64) {
+		self.corruptRecords.WithTagValues(bucketName).Inc()
+	}
+
+	var rollup *rollup
+	if config.Rollup {
+		rollup = newRollup(config.RollupStateFile, mapping.field, pkg.DefaultLogger)
+	}
+
+	var provisioner *provisioner
+	if config.Provision {
+		provisioner = newProvisioner(client, config, pkg.DefaultLogger)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &exporter{
+		client:      client,
+		config:      config,
+		mapping:     mapping,
+		backup:      backup,
+		rollup:      rollup,
+		provisioner: provisioner,
+		self:        self,
+		ctx:         ctx,
+		cancel:      cancel,
+		logger:      pkg.DefaultLogger,
+	}, nil
+}
+
+func (e *exporter) String() string {
+	return "influxdb"
+}
+
+// Close는 backup 재전송을 중지하고 집계 중인 rollup 값을 저장한 뒤 client를 닫는다.
+func (e *exporter) Close() {
+	e.cancel()
+	e.wg.Wait()
+	if e.rollup != nil {
+		e.saveRollup()
+	}
+	e.client.Close()
+}
+
+func (e *exporter) saveRollup() {
+	if err := e.rollup.save(); err != nil {
+		e.logger.Error("Failed to save rollup state(%s): %v", e.config.RollupStateFile, err)
+	}
+}
+
+// Start는 BackupDir이 설정된 경우 backup 재전송 goroutine을 시작하고,
+// Provision이 설정된 경우 organization을 미리 조회한다.
+func (e *exporter) Start(ctx context.Context) error {
+	if e.config.BackupDir != "" {
+		e.wg.Add(1)
+		go func() {
+			defer e.wg.Done()
+			e.replayLoop()
+		}()
+	}
+	if e.provisioner == nil {
+		return nil
+	}
+	return e.provisioner.start(ctx)
+}
+
+func (e *exporter) Export(ctx context.Context, metrics []dto.Metric, now time.Time) error {
+	batches := e.batches(metrics, now)
+	var errs register.MultiError
+	if e.provisioner != nil {
+		for bucketName := range batches {
+			if err := e.provisioner.ensure(ctx, bucketName); err != nil {
+				e.self.failedWriteAttempts.WithTagValues(bucketName).Inc()
+				errs = append(errs, err)
+				delete(batches, bucketName)
+			}
+		}
+	}
+
+	for bucketName, batch := range batches {
+		writeAPI := e.client.WriteAPIBlocking(e.config.Organization, bucketName)
+		if err := writeAPI.WriteRecord(ctx, batch); err != nil {
+			e.self.failedWriteAttempts.WithTagValues(bucketName).Inc()
+			errs = append(errs, fmt.Errorf("bucket(%s): %w", bucketName, err))
+			continue
+		}
+		e.written(now, bucketName)
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// ExportFailed는 기록하지 못한 batch를 backup에 저장한다.
+func (e *exporter) ExportFailed(metrics []dto.Metric, now time.Time, err error) {
+	e.mtx.Lock()
+	var batches map[string]string
+	if e.pending != nil && e.pending.now.Equal(now) {
+		batches = e.pending.batches
+		e.pending = nil
+	}
+	e.mtx.Unlock()
+	if batches == nil {
+		// 한 번도 전송하지 못하고 버려진 snapshot
+		batches = e.encode(metrics, now)
+	}
+
+	for bucketName, batch := range batches {
+		e.self.writeFailures.WithTagValues(bucketName).Inc()
+		if werr := e.backup.append(bucketName, batch); werr != nil {
+			e.logger.Error("Failed to backup batch(%s): %v (export error: %v)", bucketName, werr, err)
+			e.self.droppedPoints.WithTagValues(bucketName).Add(float64(countLines(batch)))
+			continue
+		}
+		e.logger.Warn("Backed up batch(%s): %v", bucketName, err)
+	}
+}
+
+// batches는 now에 해당하는 아직 기록하지 못한 batch를 반환한다. 처음 호출되면 snapshot을 인코딩한다.
+func (e *exporter) batches(metrics []dto.Metric, now time.Time) map[string]string {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+	if e.pending == nil || !e.pending.now.Equal(now) {
+		e.pending = &pendingBatches{now: now, batches: e.encode(metrics, now)}
+	}
+	batches := make(map[string]string, len(e.pending.batches))
+	for bucketName, batch := range e.pending.batches {
+		batches[bucketName] = batch
+	}
+	return batches
+}
+
+func (e *exporter) written(now time.Time, bucketName string) {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+	if e.pending != nil && e.pending.now.Equal(now) {
+		delete(e.pending.batches, bucketName)
+	}
+}
+
+func (e *exporter) encode(metrics []dto.Metric, now time.Time) map[string]string {
+	bucket := newBucket(e.config.precision(), e.mapping)
+	for i := range metrics {
+		if err := bucket.AddValue(&metrics[i], now); err != nil {
+			e.logger.Error("Failed to encode metric(%s.%s.%s): %v", metrics[i].Category, metrics[i].SubCategory, metrics[i].ItemName, err)
+		}
+	}
+
+	if e.rollup != nil {
+		flushed := e.rollup.observe(metrics, now, func(period string, m *dto.Metric, start time.Time) {
+			if err := bucket.addPeriod(period, m, start); err != nil {
+				e.logger.Error("Failed to encode rollup metric(%s.%s.%s): %v", m.Category, m.SubCategory, m.ItemName, err)
+			}
+		})
+		if flushed {
+			e.saveRollup()
+		}
+	}
+
+	// self metric은 사용자 metric의 bucket별 point 수를 기록한 뒤 함께 기록한다.
+	for bucketName, encoder := range bucket.items {
+		e.self.points.WithTagValues(bucketName).Add(float64(encoder.Len()))
+	}
+	for _, metric := range register.CollectAll(e.self.collectors()...) {
+		if err := bucket.Add(metric, now); err != nil {
+			e.logger.Error("Failed to encode metric(%s): %v", metric.Desc(), err)
+		}
+	}
+
+	if e.provisioner != nil {
+		for bucketName, period := range bucket.periods {
+			e.provisioner.want(bucketName, period)
+		}
+	}
+
+	return bucket.lineProtocol()
+}
+
+// replayLoop는 시작 시점과 이후 주기마다 backup을 재전송한다.
+// Export와 별도로 실행되므로 backlog가 많아도 Export의 제한 시간에 영향을 주지 않는다.
+func (e *exporter) replayLoop() {
+	ticker := time.NewTicker(e.config.replayInterval())
+	defer ticker.Stop()
+	for {
+		e.record(e.ctx)
+		select {
+		case <-ticker.C:
+		case <-e.ctx.Done():
+			return
+		}
+	}
+}
+
+func (e *exporter) record(ctx context.Context) {
+	// 재전송은 blocking API를 사용하여 성공한 batch까지만 cursor를 이동시킨다.
+	// 실패한 batch는 큐에 남아 다음 주기에 다시 전송되며, 다시 보내도 성공할 수 없는 batch는 버린다.
+	// 재시작 전에 backup된 bucket은 이번 실행의 Export에 나오지 않을 수 있으므로 재전송 전에 생성한다.
+	e.backup.replay(func(bucketName string, batch []byte) error {
+		if e.provisioner != nil {
+			if err := e.provisioner.ensure(ctx, bucketName); err != nil {
+				return err
+			}
+		}
+		writeAPI := e.client.WriteAPIBlocking(e.config.Organization, bucketName)
+		if err := writeAPI.WriteRecord(ctx, string(batch)); err != nil {
+			if !permanentWriteError(err) {
+				return err
+			}
+			points := countLines(string(batch))
+			e.logger.Error("Discarded backup batch(%s, %d points) rejected by InfluxDB: %v", bucketName, points, err)
+			e.self.discardedBatches.WithTagValues(bucketName).Inc()
+			e.self.droppedPoints.WithTagValues(bucketName).Add(float64(points))
+			return fmt.Errorf("%w: %v", errDiscardRecord, err)
+		}
+		e.self.replayedBatches.WithTagValues(bucketName).Inc()
+		return nil
+	})
+}
+
+// permanentWriteError는 같은 batch를 다시 보내도 성공할 수 없는 응답(line protocol 오류, 크기 초과, 인증 실패 등)인지 확인한다.
+// bucket이 없는 경우(404)는 bucket이 생성된 뒤 성공할 수 있으므로 408, 429와 함께 재시도한다.
+func permanentWriteError(err error) bool {
+	var httpErr *influxhttp.Error
+	if !errors.As(err, &httpErr) {
+		return false
+	}
+	switch httpErr.StatusCode {
+	case http.StatusNotFound, http.StatusRequestTimeout, http.StatusTooManyRequests:
+		return false
+	}
+	return httpErr.StatusCode >= 400 && httpErr.StatusCode < 500
+}
@@ -0,0 +1,55 @@
+package influxdb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/winey-dev/telemetry/dto"
+	"github.com/winey-dev/telemetry/metric"
+	"github.com/winey-dev/telemetry/register"
+)
+
+// counterValue는 vec에서 tagValues의 현재 값을 반환한다.
+func counterValue(t *testing.T, vec *metric.CounterVec, tagValues ...string) float64 {
+	t.Helper()
+	var value dto.Metric
+	if err := vec.WithTagValues(tagValues...).Write(&value); err != nil {
+		t.Fatal(err)
+	}
+	return value.Value
+}
+
+func TestWriteFailuresCountExhaustedRetries(t *testing.T) {
+	server := newFakeInfluxDB(t)
+	server.buckets["REALTIME_cpu"] = 0
+	server.buckets["REALTIME_"+register.ReservedCategory] = 0
+	server.failWrites = 4
+
+	e, err := NewExporter(&Config{URL: server.URL, Organization: testOrganization, BackupDir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer e.(*exporter).Close()
+	self := e.(*exporter).self
+
+	// agent가 RetryAttempts 1로 한 번 재시도한 뒤 포기한 경우
+	metrics := []dto.Metric{{Category: "cpu", SubCategory: "core", ItemName: "usage", Value: 1}}
+	now := time.Now()
+	for attempt := 0; attempt < 2; attempt++ {
+		if err := e.Export(context.Background(), metrics, now); err == nil {
+			t.Fatal("expected an error")
+		}
+	}
+	if got := counterValue(t, self.writeFailures, "REALTIME_cpu"); got != 0 {
+		t.Fatalf("write_failures = %v before retries are exhausted, want 0", got)
+	}
+	e.(register.FailureHandler).ExportFailed(metrics, now, context.DeadlineExceeded)
+
+	if got := counterValue(t, self.failedWriteAttempts, "REALTIME_cpu"); got != 2 {
+		t.Fatalf("failed_write_attempts = %v, want 2", got)
+	}
+	if got := counterValue(t, self.writeFailures, "REALTIME_cpu"); got != 1 {
+		t.Fatalf("write_failures = %v, want 1", got)
+	}
+}
@@ -0,0 +1,166 @@
+package influxdb
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/winey-dev/telemetry/dto"
+)
+
+// NameFunc는 metric과 period(REALTIME, 5MIN, HOUR, DAY)로 bucket, measurement, 필드 이름을 만든다.
+type NameFunc func(period string, m *dto.Metric) string
+
+// Mapping은 metric을 InfluxDB의 bucket, measurement, 태그, 필드로 변환하는 규칙이다.
+// 비어 있는 항목은 기본 규칙을 사용한다.
+//
+//	bucket      = <period>_<Category>
+//	measurement = SubCategory
+//	tag         = item_name=<ItemName>, metric의 태그
+//	field       = value
+//
+// 예를 들어 ItemName을 measurement와 필드 이름으로 사용하고 하나의 bucket에 기록하려면 다음과 같이 설정한다.
+//
+//	&Mapping{
+//		BucketTemplate:      "telemetry_{period}",
+//		MeasurementTemplate: "{item_name}",
+//		FieldTemplate:       "{item_name}",
+//		OmitItemNameTag:     true,
+//		CategoryTag:         "category",
+//		Tags:                map[string]string{"region": "kr"},
+//	}
+type Mapping struct {
+	Bucket      NameFunc
+	Measurement NameFunc
+	// Field는 단일 값 metric의 필드 이름이다. Histogram, Summary 등 여러 필드를 가진 metric의 필드 이름은 바뀌지 않는다.
+	Field NameFunc
+
+	// BucketTemplate, MeasurementTemplate, FieldTemplate은 NameFunc가 nil일 때 사용하는 template이다.
+	// {period}, {category}, {sub_category}, {item_name}을 사용할 수 있으며 잘못된 template은 NewExporter에서 오류를 반환한다.
+	BucketTemplate      string
+	MeasurementTemplate string
+	FieldTemplate       string
+
+	// ItemNameTag는 ItemName을 기록할 태그 이름이다. 비어 있으면 item_name을 사용한다.
+	ItemNameTag     string
+	OmitItemNameTag bool
+	// CategoryTag가 비어 있지 않으면 Category를 이 이름의 태그로 기록한다.
+	CategoryTag string
+	// Tags는 모든 point에 추가되는 태그이다. metric의 태그와 이름이 같으면 metric의 태그를 사용한다.
+	Tags map[string]string
+}
+
+// ParseTemplate은 {period}, {category}, {sub_category}, {item_name}를 metric의 값으로 바꾸는 NameFunc를 반환한다.
+// 알 수 없거나 닫히지 않은 placeholder가 있으면 ErrInvalidTemplate을 반환한다.
+func ParseTemplate(text string) (NameFunc, error) {
+	original := text
+	var segments []templateSegment
+	for text != "" {
+		open := strings.IndexByte(text, '{')
+		if open < 0 {
+			segments = append(segments, templateSegment{literal: text})
+			break
+		}
+		closing := strings.IndexByte(text[open:], '}')
+		if closing < 0 {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidTemplate, original)
+		}
+		if open > 0 {
+			segments = append(segments, templateSegment{literal: text[:open]})
+		}
+		name := text[open+1 : open+closing]
+		switch name {
+		case "period", "category", "sub_category", "item_name":
+		default:
+			return nil, fmt.Errorf("%w: %q has {%s}", ErrInvalidTemplate, original, name)
+		}
+		segments = append(segments, templateSegment{placeholder: name})
+		text = text[open+closing+1:]
+	}
+
+	return func(period string, m *dto.Metric) string {
+		if len(segments) == 1 && segments[0].placeholder == "" {
+			return segments[0].literal
+		}
+		var builder strings.Builder
+		for _, segment := range segments {
+			switch segment.placeholder {
+			case "":
+				builder.WriteString(segment.literal)
+			case "period":
+				builder.WriteString(period)
+			case "category":
+				builder.WriteString(m.Category)
+			case "sub_category":
+				builder.WriteString(m.SubCategory)
+			case "item_name":
+				builder.WriteString(m.ItemName)
+			}
+		}
+		return builder.String()
+	}, nil
+}
+
+type templateSegment struct {
+	literal     string
+	placeholder string
+}
+
+// mapping은 기본값을 채운 Mapping이다. 전역 태그는 미리 정렬해 둔다.
+type mapping struct {
+	bucket      NameFunc
+	measurement NameFunc
+	field       NameFunc
+	itemNameTag string // 비어 있으면 기록하지 않는다.
+	categoryTag string
+	tags        []tag
+}
+
+var defaultMapping, _ = newMapping(nil)
+
+func newMapping(m *Mapping) (*mapping, error) {
+	out := &mapping{
+		bucket: func(period string, m *dto.Metric) string {
+			return strings.Join([]string{period, m.Category}, "_")
+		},
+		measurement: func(_ string, m *dto.Metric) string { return m.SubCategory },
+		field:       func(string, *dto.Metric) string { return "value" },
+		itemNameTag: itemNameTag,
+	}
+	if m == nil {
+		return out, nil
+	}
+	for _, name := range []struct {
+		fn       NameFunc
+		template string
+		out      *NameFunc
+	}{
+		{m.Bucket, m.BucketTemplate, &out.bucket},
+		{m.Measurement, m.MeasurementTemplate, &out.measurement},
+		{m.Field, m.FieldTemplate, &out.field},
+	} {
+		switch {
+		case name.fn != nil:
+			*name.out = name.fn
+		case name.template != "":
+			fn, err := ParseTemplate(name.template)
+			if err != nil {
+				return nil, err
+			}
+			*name.out = fn
+		}
+	}
+	if m.ItemNameTag != "" {
+		out.itemNameTag = m.ItemNameTag
+	}
+	if m.OmitItemNameTag {
+		out.itemNameTag = ""
+	}
+	out.categoryTag = m.CategoryTag
+	for key, value := range m.Tags {
+		if key != "" && value != "" {
+			out.tags = append(out.tags, tag{key: key, value: value})
+		}
+	}
+	sortTags(out.tags)
+	return out, nil
+}
@@ -0,0 +1,42 @@
+package influxdb
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/winey-dev/telemetry/dto"
+)
+
+func TestParseTemplate(t *testing.T) {
+	m := &dto.Metric{Category: "cpu", SubCategory: "core", ItemName: "usage"}
+	tests := []struct {
+		text string
+		want string
+		err  error
+	}{
+		{"telemetry", "telemetry", nil},
+		{"telemetry_{period}", "telemetry_REALTIME", nil},
+		{"{category}.{sub_category}.{item_name}", "cpu.core.usage", nil},
+		{"{unknown}", "", ErrInvalidTemplate},
+		{"telemetry_{period", "", ErrInvalidTemplate},
+	}
+	for _, tt := range tests {
+		fn, err := ParseTemplate(tt.text)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("ParseTemplate(%q) err = %v, want %v", tt.text, err, tt.err)
+			continue
+		}
+		if err == nil {
+			if got := fn("REALTIME", m); got != tt.want {
+				t.Errorf("ParseTemplate(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		}
+	}
+}
+
+func TestNewExporterRejectsInvalidTemplate(t *testing.T) {
+	_, err := NewExporter(&Config{URL: "http://127.0.0.1:1", Mapping: &Mapping{MeasurementTemplate: "{item}"}})
+	if !errors.Is(err, ErrInvalidTemplate) {
+		t.Fatalf("err = %v, want ErrInvalidTemplate", err)
+	}
+}
@@ -0,0 +1,141 @@
+package influxdb
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	influxdb2 "github.com/influxdata/influxdb-client-go/v2"
+	"github.com/influxdata/influxdb-client-go/v2/domain"
+	"github.com/winey-dev/telemetry/pkg"
+)
+
+// provisioner는 기록할 bucket이 없으면 /api/v2/orgs, /api/v2/buckets API로 생성한다.
+// 존재가 확인된 bucket은 캐시하여 다시 조회하지 않는다.
+type provisioner struct {
+	client    influxdb2.Client
+	org       string
+	retention map[string]time.Duration
+
+	mtx     sync.Mutex
+	orgID   string
+	periods map[string]string // bucket name -> period
+	ready   map[string]bool
+
+	logger pkg.Logger
+}
+
+func newProvisioner(client influxdb2.Client, config *Config, logger pkg.Logger) *provisioner {
+	return &provisioner{
+		client:    client,
+		org:       config.Organization,
+		retention: config.Retention,
+		periods:   make(map[string]string),
+		ready:     make(map[string]bool),
+		logger:    logger,
+	}
+}
+
+// start는 organization ID를 미리 조회한다.
+func (p *provisioner) start(ctx context.Context) error {
+	_, err := p.organizationID(ctx)
+	return err
+}
+
+// want는 bucketName이 period의 값을 기록하는 bucket임을 기억한다. 보관 기간을 결정하는 데 사용한다.
+func (p *provisioner) want(bucketName, period string) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	if _, ok := p.periods[bucketName]; !ok {
+		p.periods[bucketName] = period
+	}
+}
+
+// ensure는 bucketName이 없으면 생성한다.
+func (p *provisioner) ensure(ctx context.Context, bucketName string) error {
+	p.mtx.Lock()
+	ready := p.ready[bucketName]
+	period := p.periods[bucketName]
+	p.mtx.Unlock()
+	if ready {
+		return nil
+	}
+
+	orgID, err := p.organizationID(ctx)
+	if err != nil {
+		return err
+	}
+	exists, err := p.exists(ctx, orgID, bucketName)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		retention := p.retentionOf(bucketName, period)
+		rule := domain.RetentionRule{
+			EverySeconds: int64(retention / time.Second),
+			Type:         retentionRuleType(domain.RetentionRuleTypeExpire),
+		}
+		if _, err := p.client.BucketsAPI().CreateBucketWithNameWithID(ctx, orgID, bucketName, rule); err != nil {
+			// 다른 agent가 먼저 생성한 경우
+			if exists, _ := p.exists(ctx, orgID, bucketName); !exists {
+				return fmt.Errorf("failed to create bucket(%s): %w", bucketName, err)
+			}
+		} else {
+			p.logger.Info("Created bucket(%s) with retention %s", bucketName, retention)
+		}
+	}
+
+	p.mtx.Lock()
+	p.ready[bucketName] = true
+	p.mtx.Unlock()
+	return nil
+}
+
+func (p *provisioner) organizationID(ctx context.Context) (string, error) {
+	p.mtx.Lock()
+	orgID := p.orgID
+	p.mtx.Unlock()
+	if orgID != "" {
+		return orgID, nil
+	}
+
+	org, err := p.client.OrganizationsAPI().FindOrganizationByName(ctx, p.org)
+	if err != nil {
+		return "", fmt.Errorf("failed to find organization(%s): %w", p.org, err)
+	}
+	if org.Id == nil {
+		return "", fmt.Errorf("organization(%s) has no id", p.org)
+	}
+
+	p.mtx.Lock()
+	p.orgID = *org.Id
+	p.mtx.Unlock()
+	return *org.Id, nil
+}
+
+func (p *provisioner) exists(ctx context.Context, orgID, bucketName string) (bool, error) {
+	buckets, err := p.client.APIClient().GetBuckets(ctx, &domain.GetBucketsParams{
+		OrgID: &orgID,
+		Name:  &bucketName,
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to find bucket(%s): %w", bucketName, err)
+	}
+	return buckets.Buckets != nil && len(*buckets.Buckets) > 0, nil
+}
+
+// retentionOf는 Retention에서 bucket 이름, period 순서로 보관 기간을 찾는다. 없으면 0(무기한)이다.
+func (p *provisioner) retentionOf(bucketName, period string) time.Duration {
+	if retention, ok := p.retention[bucketName]; ok {
+		return retention
+	}
+	if retention, ok := p.retention[period]; ok && period != "" {
+		return retention
+	}
+	return 0
+}
+
+func retentionRuleType(t domain.RetentionRuleType) *domain.RetentionRuleType {
+	return &t
+}
@@ -0,0 +1,203 @@
+package influxdb
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/winey-dev/telemetry/pkg"
+)
+
+const testOrganization = "telemetry"
+
+// fakeInfluxDB는 /api/v2/orgs, /api/v2/buckets, /api/v2/write만 처리하는 InfluxDB 서버이다.
+// failWrites가 0보다 크면 그 횟수만큼 write 요청에 503을 응답하고 거부한 line을 rejected에 기록한다.
+// invalid가 포함된 write 요청에는 line protocol 오류(400)를 응답한다.
+type fakeInfluxDB struct {
+	*httptest.Server
+
+	mtx        sync.Mutex
+	buckets    map[string]int64 // bucket name -> retention seconds
+	lines      map[string][]string
+	rejected   []string
+	created    []string
+	failWrites int
+	invalid    string
+}
+
+func newFakeInfluxDB(t *testing.T) *fakeInfluxDB {
+	t.Helper()
+	f := &fakeInfluxDB{buckets: make(map[string]int64), lines: make(map[string][]string)}
+	f.Server = httptest.NewServer(http.HandlerFunc(f.serve))
+	t.Cleanup(f.Close)
+	return f
+}
+
+func (f *fakeInfluxDB) serve(w http.ResponseWriter, r *http.Request) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+
+	query := r.URL.Query()
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/api/v2/orgs":
+		if query.Get("org") != testOrganization {
+			writeJSON(w, http.StatusOK, map[string]any{"orgs": []any{}})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"orgs": []any{map[string]string{"id": "org1", "name": testOrganization}}})
+	case r.Method == http.MethodGet && r.URL.Path == "/api/v2/buckets":
+		buckets := []any{}
+		if _, ok := f.buckets[query.Get("name")]; ok && query.Get("orgID") == "org1" {
+			buckets = append(buckets, map[string]string{"id": query.Get("name"), "name": query.Get("name")})
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"buckets": buckets})
+	case r.Method == http.MethodPost && r.URL.Path == "/api/v2/buckets":
+		var body struct {
+			OrgID          string `json:"orgID"`
+			Name           string `json:"name"`
+			RetentionRules []struct {
+				EverySeconds int64 `json:"everySeconds"`
+			} `json:"retentionRules"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.OrgID != "org1" {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"code": "invalid", "message": "invalid bucket"})
+			return
+		}
+		if _, ok := f.buckets[body.Name]; ok {
+			writeJSON(w, http.StatusUnprocessableEntity, map[string]string{"code": "conflict", "message": "bucket exists"})
+			return
+		}
+		var retention int64
+		if len(body.RetentionRules) > 0 {
+			retention = body.RetentionRules[0].EverySeconds
+		}
+		f.buckets[body.Name] = retention
+		f.created = append(f.created, body.Name)
+		writeJSON(w, http.StatusCreated, map[string]any{"id": body.Name, "name": body.Name, "orgID": body.OrgID, "retentionRules": body.RetentionRules})
+	case r.Method == http.MethodPost && r.URL.Path == "/api/v2/write":
+		bucketName := query.Get("bucket")
+		if _, ok := f.buckets[bucketName]; !ok {
+			writeJSON(w, http.StatusNotFound, map[string]string{"code": "not found", "message": "bucket not found"})
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		lines := strings.FieldsFunc(string(body), func(r rune) bool { return r == '\n' })
+		if f.invalid != "" && strings.Contains(string(body), f.invalid) {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"code": "invalid", "message": "unable to parse line"})
+			return
+		}
+		if f.failWrites > 0 {
+			f.failWrites--
+			f.rejected = append(f.rejected, lines...)
+			writeJSON(w, http.StatusServiceUnavailable, map[string]string{"code": "unavailable", "message": "unavailable"})
+			return
+		}
+		f.lines[bucketName] = append(f.lines[bucketName], lines...)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeJSON(w, http.StatusNotFound, map[string]string{"code": "not found", "message": r.URL.Path})
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func (f *fakeInfluxDB) createdBuckets() []string {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	return append([]string(nil), f.created...)
+}
+
+func (f *fakeInfluxDB) bucketLines(bucketName string) []string {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	return append([]string(nil), f.lines[bucketName]...)
+}
+
+func TestProvisionerCreatesMissingBucket(t *testing.T) {
+	server := newFakeInfluxDB(t)
+	server.buckets["REALTIME_cpu"] = 0
+
+	config := &Config{
+		URL:          server.URL,
+		Organization: testOrganization,
+		Retention:    map[string]time.Duration{"HOUR": 30 * 24 * time.Hour, "DAY_cpu": 0},
+	}
+	e, err := NewExporter(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer e.(*exporter).Close()
+	p := newProvisioner(e.(*exporter).client, config, pkg.DefaultLogger)
+	ctx := context.Background()
+	if err := p.start(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	p.want("HOUR_cpu", "HOUR")
+	for _, bucketName := range []string{"REALTIME_cpu", "HOUR_cpu", "HOUR_cpu", "DAY_cpu"} {
+		if err := p.ensure(ctx, bucketName); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := server.createdBuckets(); strings.Join(got, ",") != "HOUR_cpu,DAY_cpu" {
+		t.Fatalf("created %v, want [HOUR_cpu DAY_cpu]", got)
+	}
+	if got := server.buckets["HOUR_cpu"]; got != int64(30*24*time.Hour/time.Second) {
+		t.Fatalf("HOUR_cpu retention = %ds", got)
+	}
+	if got := server.buckets["DAY_cpu"]; got != 0 {
+		t.Fatalf("DAY_cpu retention = %ds, want 0", got)
+	}
+}
+
+func TestProvisionerUnknownOrganization(t *testing.T) {
+	server := newFakeInfluxDB(t)
+	e, err := NewExporter(&Config{URL: server.URL, Organization: "unknown", Provision: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer e.(*exporter).Close()
+	if err := e.(*exporter).provisioner.ensure(context.Background(), "REALTIME_cpu"); err == nil {
+		t.Fatal("expected an error for an unknown organization")
+	}
+}
+
+func TestReplayProvisionsBacklogBuckets(t *testing.T) {
+	server := newFakeInfluxDB(t)
+	config := &Config{
+		URL:          server.URL,
+		Organization: testOrganization,
+		BackupDir:    t.TempDir(),
+		Provision:    true,
+	}
+
+	// 이전 실행에서 기록하지 못한 bucket으로 이번 실행의 Export에는 나오지 않는다.
+	b := newBackup(config, pkg.DefaultLogger)
+	if err := b.append("REALTIME_legacy", "disk value=1 1\n"); err != nil {
+		t.Fatal(err)
+	}
+
+	e, err := NewExporter(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer e.(*exporter).Close()
+	e.(*exporter).record(context.Background())
+
+	if got := server.createdBuckets(); len(got) != 1 || got[0] != "REALTIME_legacy" {
+		t.Fatalf("created %v, want [REALTIME_legacy]", got)
+	}
+	if got := server.bucketLines("REALTIME_legacy"); len(got) != 1 || got[0] != "disk value=1 1" {
+		t.Fatalf("lines = %v", got)
+	}
+}
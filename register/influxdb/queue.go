@@ -0,0 +1,497 @@
+package influxdb
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 세그먼트 파일 구조
+//
+//	header: magic(4) | version(4) | created unix nano(8) | crc32(4)
+//	record: length(4) | crc32(4) | payload(length)
+//
+// 마지막 세그먼트의 꼬리 부분이 손상된 경우(쓰기 도중 crash) open 시 마지막 정상 record 위치로 잘라내고,
+// 재전송 중 CRC가 맞지 않는 record를 발견하면 그 record부터 세그먼트 끝까지 잘라낸다.
+const (
+	segmentExt        = ".seg"
+	segmentMagic      = "TLQS"
+	segmentVersion    = 1
+	segmentHeaderSize = 20
+	recordHeaderSize  = 8
+
+	cursorFileName = "cursor"
+	cursorSize     = 20
+
+	defaultSegmentBytes = 4 << 20
+	maxRecordBytes      = 64 << 20
+)
+
+var (
+	errCorruptSegment = errors.New("corrupt segment")
+	errCorruptRecord  = errors.New("corrupt record")
+	// errDiscardRecord를 감싼 오류를 replay의 fn이 반환하면 record를 버리고 다음 record로 진행한다.
+	errDiscardRecord = errors.New("discard record")
+)
+
+type segment struct {
+	seq     uint64
+	path    string
+	size    int64
+	created time.Time
+	modTime time.Time
+}
+
+// cursor는 재전송이 완료된 위치를 가리킨다. 재시작 시 이미 전송한 record를 다시 보내지 않기 위해 사용한다.
+type cursor struct {
+	seq    uint64
+	offset int64
+}
+
+// queue는 하나의 bucket에 대한 세그먼트 기반 디스크 큐이다.
+type queue struct {
+	mtx          sync.Mutex
+	dir          string
+	segmentBytes int64
+	segments     []*segment
+	cursor       cursor
+
+	// onCorrupt는 손상된 record를 발견하여 offset부터 discarded 바이트를 잘라낼 때 호출된다.
+	onCorrupt func(path string, offset, discarded int64)
+}
+
+func openQueue(dir string, segmentBytes int64, onCorrupt func(path string, offset, discarded int64)) (*queue, error) {
+	if segmentBytes <= 0 {
+		segmentBytes = defaultSegmentBytes
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	q := &queue{dir: dir, segmentBytes: segmentBytes, onCorrupt: onCorrupt}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, segmentExt) {
+			continue
+		}
+		seq, err := strconv.ParseUint(strings.TrimSuffix(name, segmentExt), 10, 64)
+		if err != nil {
+			continue
+		}
+		seg, err := readSegment(filepath.Join(dir, name), seq)
+		if err != nil {
+			// header가 손상된 세그먼트는 복구할 수 없다.
+			os.Remove(filepath.Join(dir, name))
+			continue
+		}
+		q.segments = append(q.segments, seg)
+	}
+	sort.Slice(q.segments, func(i, j int) bool { return q.segments[i].seq < q.segments[j].seq })
+
+	if len(q.segments) > 0 {
+		if err := q.recoverTail(q.segments[len(q.segments)-1]); err != nil {
+			return nil, err
+		}
+	}
+	q.cursor = q.loadCursor()
+	return q, nil
+}
+
+func segmentPath(dir string, seq uint64) string {
+	return filepath.Join(dir, fmt.Sprintf("%020d%s", seq, segmentExt))
+}
+
+func readSegment(path string, seq uint64) (*segment, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	var header [segmentHeaderSize]byte
+	if _, err := io.ReadFull(f, header[:]); err != nil {
+		return nil, errCorruptSegment
+	}
+	if string(header[0:4]) != segmentMagic ||
+		binary.BigEndian.Uint32(header[4:8]) != segmentVersion ||
+		binary.BigEndian.Uint32(header[16:20]) != crc32.ChecksumIEEE(header[:16]) {
+		return nil, errCorruptSegment
+	}
+	return &segment{
+		seq:     seq,
+		path:    path,
+		size:    info.Size(),
+		created: time.Unix(0, int64(binary.BigEndian.Uint64(header[8:16]))),
+		modTime: info.ModTime(),
+	}, nil
+}
+
+// recoverTail은 세그먼트 끝에 일부만 기록된 record를 잘라낸다.
+func (q *queue) recoverTail(seg *segment) error {
+	f, err := os.Open(seg.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := f.Seek(segmentHeaderSize, io.SeekStart); err != nil {
+		return err
+	}
+	offset := int64(segmentHeaderSize)
+	r := bufio.NewReader(f)
+	for {
+		n, _, err := readRecord(r)
+		if err != nil {
+			break
+		}
+		offset += n
+	}
+	if offset == seg.size {
+		return nil
+	}
+	return q.truncate(seg, offset)
+}
+
+// truncate는 손상되거나 일부만 기록된 record가 시작되는 offset부터 seg를 잘라내고 onCorrupt로 알린다.
+func (q *queue) truncate(seg *segment, offset int64) error {
+	f, err := os.OpenFile(seg.path, os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := f.Truncate(offset); err != nil {
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		return err
+	}
+	if q.onCorrupt != nil {
+		q.onCorrupt(seg.path, offset, seg.size-offset)
+	}
+	seg.size = offset
+	return nil
+}
+
+func readRecord(r io.Reader) (int64, []byte, error) {
+	var header [recordHeaderSize]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return 0, nil, errCorruptRecord
+		}
+		return 0, nil, err
+	}
+	length := binary.BigEndian.Uint32(header[0:4])
+	if length > maxRecordBytes {
+		return 0, nil, errCorruptRecord
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return 0, nil, errCorruptRecord
+	}
+	if crc32.ChecksumIEEE(payload) != binary.BigEndian.Uint32(header[4:8]) {
+		return 0, nil, errCorruptRecord
+	}
+	return int64(recordHeaderSize) + int64(length), payload, nil
+}
+
+func (q *queue) newSegment(now time.Time) (*segment, error) {
+	var seq uint64 = 1
+	if len(q.segments) > 0 {
+		seq = q.segments[len(q.segments)-1].seq + 1
+	}
+	path := segmentPath(q.dir, seq)
+
+	var header [segmentHeaderSize]byte
+	copy(header[0:4], segmentMagic)
+	binary.BigEndian.PutUint32(header[4:8], segmentVersion)
+	binary.BigEndian.PutUint64(header[8:16], uint64(now.UnixNano()))
+	binary.BigEndian.PutUint32(header[16:20], crc32.ChecksumIEEE(header[:16]))
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := f.Write(header[:]); err != nil {
+		f.Close()
+		os.Remove(path)
+		return nil, err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		os.Remove(path)
+		return nil, err
+	}
+	if err := f.Close(); err != nil {
+		return nil, err
+	}
+	if err := syncDir(q.dir); err != nil {
+		return nil, err
+	}
+	seg := &segment{seq: seq, path: path, size: segmentHeaderSize, created: now, modTime: now}
+	q.segments = append(q.segments, seg)
+	return seg, nil
+}
+
+// append는 record 하나를 마지막 세그먼트에 기록하고 sync한다. segmentBytes를 넘게 되면 새 세그먼트를 만든다.
+func (q *queue) append(data []byte, now time.Time) error {
+	if len(data) > maxRecordBytes {
+		return fmt.Errorf("record too large: %d bytes", len(data))
+	}
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+
+	recordSize := int64(recordHeaderSize + len(data))
+	var seg *segment
+	if n := len(q.segments); n > 0 {
+		seg = q.segments[n-1]
+		if seg.size > segmentHeaderSize && seg.size+recordSize > q.segmentBytes {
+			seg = nil
+		}
+	}
+	if seg == nil {
+		var err error
+		if seg, err = q.newSegment(now); err != nil {
+			return err
+		}
+	}
+
+	record := make([]byte, recordSize)
+	binary.BigEndian.PutUint32(record[0:4], uint32(len(data)))
+	binary.BigEndian.PutUint32(record[4:8], crc32.ChecksumIEEE(data))
+	copy(record[recordHeaderSize:], data)
+
+	f, err := os.OpenFile(seg.path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(record); err != nil {
+		f.Close()
+		// 일부만 기록된 record는 다음 open 시 recoverTail에서 정리된다.
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	seg.size += recordSize
+	seg.modTime = now
+	return nil
+}
+
+// replay는 cursor 위치부터 오래된 순서로 record를 fn에 전달하고 전달한 record 수를 반환한다.
+// fn이 성공하거나 errDiscardRecord를 반환한 record마다 cursor를 저장하며, 그 밖의 오류에서는 실패한 record를
+// 큐에 남기고 멈춘다. 느린 전송이 append와 stats를 막지 않도록 fn을 호출하는 동안에는 q.mtx를 잡지 않는다.
+func (q *queue) replay(fn func([]byte) error) (int, error) {
+	replayed := 0
+	for {
+		payload, at, n, ok, err := q.next()
+		if err != nil || !ok {
+			return replayed, err
+		}
+		if err := fn(payload); err != nil && !errors.Is(err, errDiscardRecord) {
+			return replayed, err
+		}
+		if err := q.advance(at, n); err != nil {
+			return replayed, err
+		}
+		replayed++
+	}
+}
+
+// next는 cursor 위치의 record와 그 위치, 크기를 반환한다. 재전송이 끝난 세그먼트는 삭제하고,
+// 손상된 record는 세그먼트의 나머지 부분과 함께 잘라낸다. 재전송할 record가 없으면 false를 반환한다.
+func (q *queue) next() ([]byte, cursor, int64, bool, error) {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+
+	for len(q.segments) > 0 {
+		seg := q.segments[0]
+		if q.cursor.seq != seg.seq || q.cursor.offset < segmentHeaderSize {
+			q.cursor = cursor{seq: seg.seq, offset: segmentHeaderSize}
+		}
+		if q.cursor.offset < seg.size {
+			n, payload, err := readRecordAt(seg.path, q.cursor.offset)
+			switch {
+			case err == nil:
+				return payload, q.cursor, n, true, nil
+			case err == errCorruptRecord:
+				// 손상된 record 이후의 데이터는 신뢰할 수 없으므로 마지막 정상 record 위치로 잘라낸다.
+				if err := q.truncate(seg, q.cursor.offset); err != nil {
+					return nil, cursor{}, 0, false, err
+				}
+			case err != io.EOF:
+				return nil, cursor{}, 0, false, err
+			}
+		}
+		if err := q.removeFirst(); err != nil {
+			return nil, cursor{}, 0, false, err
+		}
+	}
+	return nil, cursor{}, 0, false, nil
+}
+
+// advance는 at에서 읽은 크기 n의 record 다음으로 cursor를 옮긴다.
+// 전송하는 동안 세그먼트가 삭제되어 cursor가 바뀌었으면 옮기지 않는다.
+func (q *queue) advance(at cursor, n int64) error {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+	if q.cursor != at {
+		return nil
+	}
+	q.cursor.offset += n
+	return q.saveCursor()
+}
+
+func readRecordAt(path string, offset int64) (int64, []byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer f.Close()
+
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return 0, nil, err
+	}
+	return readRecord(bufio.NewReader(f))
+}
+
+func (q *queue) removeFirst() error {
+	if len(q.segments) == 0 {
+		return nil
+	}
+	seg := q.segments[0]
+	if err := os.Remove(seg.path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	q.segments[0] = nil
+	q.segments = q.segments[1:]
+	if q.cursor.seq == seg.seq {
+		q.cursor = cursor{}
+		if err := q.saveCursor(); err != nil {
+			return err
+		}
+	}
+	return syncDir(q.dir)
+}
+
+// evictOldest는 가장 오래된 세그먼트를 삭제하고 그 크기를 반환한다.
+func (q *queue) evictOldest() (int64, error) {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+	if len(q.segments) == 0 {
+		return 0, nil
+	}
+	size := q.segments[0].size
+	return size, q.removeFirst()
+}
+
+// expire는 마지막 record가 maxAge보다 오래된 세그먼트를 삭제한다.
+func (q *queue) expire(maxAge time.Duration, now time.Time) (int, error) {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+
+	removed := 0
+	for len(q.segments) > 0 && now.Sub(q.segments[0].modTime) > maxAge {
+		if err := q.removeFirst(); err != nil {
+			return removed, err
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+func (q *queue) size() int64 {
+	size, _ := q.stats()
+	return size
+}
+
+// stats는 전체 크기와 세그먼트 수를 반환한다.
+func (q *queue) stats() (int64, int) {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+	var total int64
+	for _, seg := range q.segments {
+		total += seg.size
+	}
+	return total, len(q.segments)
+}
+
+func (q *queue) oldest() (time.Time, bool) {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+	if len(q.segments) == 0 {
+		return time.Time{}, false
+	}
+	return q.segments[0].created, true
+}
+
+func (q *queue) loadCursor() cursor {
+	data, err := os.ReadFile(filepath.Join(q.dir, cursorFileName))
+	if err != nil || len(data) != cursorSize {
+		return cursor{}
+	}
+	if binary.BigEndian.Uint32(data[16:20]) != crc32.ChecksumIEEE(data[:16]) {
+		return cursor{}
+	}
+	return cursor{
+		seq:    binary.BigEndian.Uint64(data[0:8]),
+		offset: int64(binary.BigEndian.Uint64(data[8:16])),
+	}
+}
+
+func (q *queue) saveCursor() error {
+	var data [cursorSize]byte
+	binary.BigEndian.PutUint64(data[0:8], q.cursor.seq)
+	binary.BigEndian.PutUint64(data[8:16], uint64(q.cursor.offset))
+	binary.BigEndian.PutUint32(data[16:20], crc32.ChecksumIEEE(data[:16]))
+	return writeFileAtomic(filepath.Join(q.dir, cursorFileName), data[:])
+}
+
+// writeFileAtomic은 data를 임시 파일에 기록하고 sync한 뒤 path로 rename한다.
+func writeFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".*"+backupTempExt)
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath) // rename 이후에는 no-op
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return err
+	}
+	return syncDir(dir)
+}
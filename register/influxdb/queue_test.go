@@ -0,0 +1,165 @@
+package influxdb
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// corruptions는 queue의 onCorrupt 호출을 기록한다.
+type corruptions struct {
+	discarded []int64
+}
+
+func (c *corruptions) record(_ string, _ int64, discarded int64) {
+	c.discarded = append(c.discarded, discarded)
+}
+
+func openTestQueue(t *testing.T, dir string, segmentBytes int64, c *corruptions) *queue {
+	t.Helper()
+	q, err := openQueue(dir, segmentBytes, c.record)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return q
+}
+
+func appendRecords(t *testing.T, q *queue, records ...string) {
+	t.Helper()
+	for _, record := range records {
+		if err := q.append([]byte(record), time.Now()); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// replayAll은 큐의 모든 record를 재전송하고 받은 순서대로 반환한다.
+func replayAll(t *testing.T, q *queue) []string {
+	t.Helper()
+	var got []string
+	if _, err := q.replay(func(payload []byte) error {
+		got = append(got, string(payload))
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return got
+}
+
+func TestQueueRecoversTornTail(t *testing.T) {
+	dir := t.TempDir()
+	var c corruptions
+	q := openTestQueue(t, dir, 0, &c)
+	appendRecords(t, q, "a", "b", "c")
+
+	// record header와 payload 일부만 기록된 상태에서 crash가 발생한 경우
+	seg := q.segments[len(q.segments)-1]
+	f, err := os.OpenFile(seg.path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var header [recordHeaderSize]byte
+	binary.BigEndian.PutUint32(header[0:4], 10)
+	if _, err := f.Write(append(header[:], "dd"...)); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	q = openTestQueue(t, dir, 0, &c)
+	if want := []int64{recordHeaderSize + 2}; !reflect.DeepEqual(c.discarded, want) {
+		t.Fatalf("discarded = %v, want %v", c.discarded, want)
+	}
+	appendRecords(t, q, "e")
+	if got, want := replayAll(t, q), []string{"a", "b", "c", "e"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("replayed %v, want %v", got, want)
+	}
+}
+
+func TestQueueDiscardsAfterCorruptRecord(t *testing.T) {
+	dir := t.TempDir()
+	var c corruptions
+	// 세그먼트 하나에 record 두 개가 들어가는 크기
+	q := openTestQueue(t, dir, segmentHeaderSize+2*(recordHeaderSize+1), &c)
+	appendRecords(t, q, "a", "b", "c", "d")
+	if len(q.segments) != 2 {
+		t.Fatalf("segments = %d, want 2", len(q.segments))
+	}
+
+	// 첫 번째 세그먼트의 두 번째 record payload를 변경하여 CRC가 맞지 않게 한다.
+	f, err := os.OpenFile(q.segments[0].path, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteAt([]byte("x"), segmentHeaderSize+recordHeaderSize+1+recordHeaderSize); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	if got, want := replayAll(t, q), []string{"a", "c", "d"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("replayed %v, want %v", got, want)
+	}
+	if want := []int64{recordHeaderSize + 1}; !reflect.DeepEqual(c.discarded, want) {
+		t.Fatalf("discarded = %v, want %v", c.discarded, want)
+	}
+	if size, segments := q.stats(); size != 0 || segments != 0 {
+		t.Fatalf("stats = %d bytes, %d segments, want empty", size, segments)
+	}
+}
+
+func TestQueuePersistsCursor(t *testing.T) {
+	dir := t.TempDir()
+	var c corruptions
+	q := openTestQueue(t, dir, 0, &c)
+	appendRecords(t, q, "a", "b", "c")
+
+	errWrite := errors.New("write failed")
+	var sent []string
+	replayed, err := q.replay(func(payload []byte) error {
+		if string(payload) == "c" {
+			return errWrite
+		}
+		sent = append(sent, string(payload))
+		return nil
+	})
+	if !errors.Is(err, errWrite) || replayed != 2 {
+		t.Fatalf("replay = %d, %v, want 2, %v", replayed, err, errWrite)
+	}
+
+	// 재시작 후에는 전송하지 못한 record만 다시 보낸다.
+	q = openTestQueue(t, dir, 0, &c)
+	if got, want := replayAll(t, q), []string{"c"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("replayed %v after restart, want %v", got, want)
+	}
+	q = openTestQueue(t, dir, 0, &c)
+	if got := replayAll(t, q); len(got) != 0 {
+		t.Fatalf("replayed %v after the queue was drained", got)
+	}
+	if len(c.discarded) != 0 {
+		t.Fatalf("discarded = %v, want none", c.discarded)
+	}
+}
+
+func TestQueueReplayDoesNotHoldLock(t *testing.T) {
+	q := openTestQueue(t, t.TempDir(), 0, &corruptions{})
+	appendRecords(t, q, "a", "b")
+
+	// fn이 실행되는 동안 append와 stats가 blocking되지 않아야 한다.
+	var got []string
+	if _, err := q.replay(func(payload []byte) error {
+		got = append(got, string(payload))
+		if len(got) <= 2 {
+			q.stats()
+			return q.append([]byte(fmt.Sprintf("during-%d", len(got))), time.Now())
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"a", "b", "during-1", "during-2"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("replayed %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,291 @@
+package influxdb
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/winey-dev/telemetry/dto"
+	"github.com/winey-dev/telemetry/pkg"
+)
+
+const rollupStateVersion = 1
+
+type rollupPeriod struct {
+	name     string
+	duration time.Duration
+}
+
+// rollupPeriods는 REALTIME 값을 집계하는 주기이다. 구간은 UTC 기준 wall-clock 경계(예: 매 정시)에 맞춰진다.
+var rollupPeriods = []rollupPeriod{
+	{name: "5MIN", duration: 5 * time.Minute},
+	{name: "HOUR", duration: time.Hour},
+	{name: "DAY", duration: 24 * time.Hour},
+}
+
+// rollup은 series 별로 REALTIME 값의 sum, min, max, count, last를 주기마다 누적하고
+// 구간이 끝나면 <주기>_<category> bucket에 구간 시작 시각으로 기록한다.
+// 집계 중인 값은 구간이 끝날 때와 exporter를 닫을 때 path에 저장되어 재시작 후에도 이어서 집계된다.
+type rollup struct {
+	mtx     sync.Mutex
+	path    string
+	windows []*rollupWindow
+	field   NameFunc // 단일 값 metric의 필드 이름
+	logger  pkg.Logger
+}
+
+type rollupState struct {
+	Version int             `json:"version"`
+	Windows []*rollupWindow `json:"windows"`
+}
+
+type rollupWindow struct {
+	Period string                   `json:"period"`
+	Start  time.Time                `json:"start"`
+	Series map[string]*rollupSeries `json:"series"`
+
+	duration time.Duration
+}
+
+type rollupSeries struct {
+	Category    string                      `json:"category"`
+	SubCategory string                      `json:"sub_category"`
+	ItemName    string                      `json:"item_name"`
+	TagNames    []string                    `json:"tag_names,omitempty"`
+	TagValues   []string                    `json:"tag_values,omitempty"`
+	Fields      map[string]*rollupAggregate `json:"fields"`
+}
+
+type rollupAggregate struct {
+	Sum   float64 `json:"sum"`
+	Min   float64 `json:"min"`
+	Max   float64 `json:"max"`
+	Last  float64 `json:"last"`
+	Count uint64  `json:"count"`
+}
+
+func newRollup(path string, field NameFunc, logger pkg.Logger) *rollup {
+	r := &rollup{path: path, field: field, logger: logger}
+	for _, period := range rollupPeriods {
+		r.windows = append(r.windows, &rollupWindow{
+			Period:   period.name,
+			Series:   make(map[string]*rollupSeries),
+			duration: period.duration,
+		})
+	}
+	if err := r.load(); err != nil {
+		logger.Error("Failed to load rollup state(%s): %v", path, err)
+	}
+	return r
+}
+
+// observe는 metrics를 now가 속한 구간에 누적한다. 이전 구간이 끝났으면 먼저 emit으로 집계 결과를 전달하고 true를 반환한다.
+func (r *rollup) observe(metrics []dto.Metric, now time.Time, emit func(period string, m *dto.Metric, start time.Time)) bool {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	flushed := false
+	for _, w := range r.windows {
+		start := now.Truncate(w.duration)
+		if start.Before(w.Start) {
+			// 이미 지난 구간의 값(예: 늦게 처리된 snapshot)은 집계하지 않는다.
+			continue
+		}
+		if !w.Start.Equal(start) {
+			if !w.Start.IsZero() {
+				w.flush(emit)
+				flushed = true
+			}
+			w.Start = start
+			w.Series = make(map[string]*rollupSeries)
+		}
+		for i := range metrics {
+			w.add(&metrics[i], r.field(w.Period, &metrics[i]))
+		}
+	}
+	return flushed
+}
+
+func (w *rollupWindow) add(m *dto.Metric, valueField string) {
+	key := seriesKey(m)
+	series, ok := w.Series[key]
+	if !ok {
+		// m은 agent의 snapshot이므로 구간이 끝날 때까지 보관하는 태그는 복사한다.
+		series = &rollupSeries{
+			Category:    m.Category,
+			SubCategory: m.SubCategory,
+			ItemName:    m.ItemName,
+			TagNames:    append([]string(nil), m.TagNames...),
+			TagValues:   append([]string(nil), m.TagValues...),
+			Fields:      make(map[string]*rollupAggregate),
+		}
+	} else if series.Fields == nil {
+		series.Fields = make(map[string]*rollupAggregate)
+	}
+	added := false
+	numericFields(m, valueField, func(name string, v float64) {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return
+		}
+		added = true
+		agg, ok := series.Fields[name]
+		if !ok {
+			series.Fields[name] = &rollupAggregate{Sum: v, Min: v, Max: v, Last: v, Count: 1}
+			return
+		}
+		agg.Sum += v
+		agg.Min = math.Min(agg.Min, v)
+		agg.Max = math.Max(agg.Max, v)
+		agg.Last = v
+		agg.Count++
+	})
+	if added && !ok {
+		w.Series[key] = series
+	}
+}
+
+// flush는 구간의 series를 <field>_sum, <field>_min, <field>_max, <field>_count, <field>_last 필드로 전달한다.
+func (w *rollupWindow) flush(emit func(period string, m *dto.Metric, start time.Time)) {
+	for _, series := range w.Series {
+		names := make([]string, 0, len(series.Fields))
+		for name := range series.Fields {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		fields := make([]dto.Field, 0, len(names)*5)
+		for _, name := range names {
+			agg := series.Fields[name]
+			fields = append(fields,
+				dto.Field{Name: name + "_sum", Value: agg.Sum},
+				dto.Field{Name: name + "_min", Value: agg.Min},
+				dto.Field{Name: name + "_max", Value: agg.Max},
+				dto.Field{Name: name + "_count", Value: float64(agg.Count)},
+				dto.Field{Name: name + "_last", Value: agg.Last},
+			)
+		}
+		emit(w.Period, &dto.Metric{
+			Category:    series.Category,
+			SubCategory: series.SubCategory,
+			ItemName:    series.ItemName,
+			TagNames:    series.TagNames,
+			TagValues:   series.TagValues,
+			Fields:      fields,
+		}, w.Start)
+	}
+}
+
+func seriesKey(m *dto.Metric) string {
+	var builder strings.Builder
+	builder.WriteString(m.Category)
+	builder.WriteByte(0xff)
+	builder.WriteString(m.SubCategory)
+	builder.WriteByte(0xff)
+	builder.WriteString(m.ItemName)
+	for i, tagName := range m.TagNames {
+		builder.WriteByte(0xff)
+		builder.WriteString(tagName)
+		builder.WriteByte('=')
+		if i < len(m.TagValues) {
+			builder.WriteString(m.TagValues[i])
+		}
+	}
+	return builder.String()
+}
+
+// numericFields는 REALTIME bucket에 기록되는 필드 중 숫자 필드를 fn에 전달한다. 문자열과 bool 값은 집계하지 않는다.
+func numericFields(m *dto.Metric, valueField string, fn func(name string, v float64)) {
+	switch {
+	case m.Histogram != nil:
+		for _, bucket := range m.Histogram.Buckets {
+			fn("le_"+strconv.FormatFloat(bucket.UpperBound, 'g', -1, 64), float64(bucket.Count))
+		}
+		fn("le_+Inf", float64(m.Histogram.Count))
+		fn("sum", m.Histogram.Sum)
+		fn("count", float64(m.Histogram.Count))
+	case m.Summary != nil:
+		for _, q := range m.Summary.Quantiles {
+			fn("p"+strconv.FormatFloat(quantilePercent(q.Quantile), 'f', -1, 64), q.Value)
+		}
+		fn("sum", m.Summary.Sum)
+		fn("count", float64(m.Summary.Count))
+	case m.Aggregation != nil:
+		if m.Aggregation.Count > 0 {
+			fn("mean", m.Aggregation.Mean)
+			fn("min", m.Aggregation.Min)
+			fn("max", m.Aggregation.Max)
+		}
+		fn("sum", m.Aggregation.Sum)
+		fn("count", float64(m.Aggregation.Count))
+	case len(m.Fields) > 0:
+		for _, field := range m.Fields {
+			fn(field.Name, field.Value)
+		}
+	default:
+		switch m.ValueType {
+		case dto.ValueInt:
+			fn(valueField, float64(m.IntValue))
+		case dto.ValueUint:
+			fn(valueField, float64(m.UintValue))
+		case dto.ValueFloat:
+			fn(valueField, m.Value)
+		}
+	}
+}
+
+// save는 집계 중인 값을 path에 저장한다.
+func (r *rollup) save() error {
+	if r.path == "" {
+		return nil
+	}
+	r.mtx.Lock()
+	data, err := json.Marshal(&rollupState{Version: rollupStateVersion, Windows: r.windows})
+	r.mtx.Unlock()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return err
+	}
+	return writeFileAtomic(r.path, data)
+}
+
+// load는 저장된 집계 값을 읽는다. 알 수 없는 주기와 길이가 바뀐 구간은 무시한다.
+func (r *rollup) load() error {
+	if r.path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(r.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var state rollupState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return err
+	}
+	if state.Version != rollupStateVersion {
+		return errors.New("unsupported rollup state version: " + strconv.Itoa(state.Version))
+	}
+	for _, saved := range state.Windows {
+		for _, w := range r.windows {
+			if w.Period != saved.Period || !saved.Start.Equal(saved.Start.Truncate(w.duration)) {
+				continue
+			}
+			w.Start = saved.Start
+			if saved.Series != nil {
+				w.Series = saved.Series
+			}
+		}
+	}
+	return nil
+}
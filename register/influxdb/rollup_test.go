@@ -0,0 +1,123 @@
+package influxdb
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/winey-dev/telemetry/dto"
+	"github.com/winey-dev/telemetry/pkg"
+)
+
+// emitted는 rollup이 구간이 끝날 때 전달한 값이다.
+type emitted struct {
+	period string
+	metric dto.Metric
+	start  time.Time
+}
+
+func observe(r *rollup, now time.Time, metrics ...dto.Metric) ([]emitted, bool) {
+	var out []emitted
+	flushed := r.observe(metrics, now, func(period string, m *dto.Metric, start time.Time) {
+		out = append(out, emitted{period: period, metric: *m, start: start})
+	})
+	return out, flushed
+}
+
+func gauge(value float64, tagValues ...string) dto.Metric {
+	return dto.Metric{Category: "cpu", SubCategory: "core", ItemName: "usage", TagNames: []string{"core"}, TagValues: tagValues, Value: value}
+}
+
+func fieldValues(m dto.Metric) map[string]float64 {
+	out := make(map[string]float64, len(m.Fields))
+	for _, field := range m.Fields {
+		out[field.Name] = field.Value
+	}
+	return out
+}
+
+func TestRollupWindowRollover(t *testing.T) {
+	// 필드 이름은 rollup 구간의 period로 만든다.
+	field := func(period string, _ *dto.Metric) string { return strings.ToLower(period) }
+	r := newRollup("", field, pkg.DefaultLogger)
+	t0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	metrics := []dto.Metric{gauge(1, "0")}
+	if out, flushed := observe(r, t0, metrics...); len(out) != 0 || flushed {
+		t.Fatalf("emitted %v at the first observation", out)
+	}
+	// snapshot의 태그 slice를 재사용하더라도 집계 중인 series의 태그는 바뀌지 않아야 한다.
+	metrics[0].TagValues[0] = "changed"
+	observe(r, t0.Add(time.Minute), gauge(3, "0"))
+	observe(r, t0.Add(4*time.Minute), gauge(2, "0"))
+
+	out, flushed := observe(r, t0.Add(5*time.Minute), gauge(10, "0"))
+	if !flushed || len(out) != 1 {
+		t.Fatalf("emitted %v, flushed %t, want one 5MIN series", out, flushed)
+	}
+	got := out[0]
+	if got.period != "5MIN" || !got.start.Equal(t0) {
+		t.Fatalf("emitted period %s start %s, want 5MIN %s", got.period, got.start, t0)
+	}
+	if !reflect.DeepEqual(got.metric.TagValues, []string{"0"}) {
+		t.Fatalf("tag values = %v, want [0]", got.metric.TagValues)
+	}
+	want := map[string]float64{"5min_sum": 6, "5min_min": 1, "5min_max": 3, "5min_count": 3, "5min_last": 2}
+	if values := fieldValues(got.metric); !reflect.DeepEqual(values, want) {
+		t.Fatalf("fields = %v, want %v", values, want)
+	}
+
+	// 정시에는 5MIN과 HOUR 구간이 함께 끝난다.
+	out, _ = observe(r, t0.Add(time.Hour), gauge(1, "0"))
+	periods := make([]string, 0, len(out))
+	for _, e := range out {
+		periods = append(periods, e.period)
+	}
+	if strings.Join(periods, ",") != "5MIN,HOUR" {
+		t.Fatalf("emitted periods %v, want [5MIN HOUR]", periods)
+	}
+	if values := fieldValues(out[1].metric); values["hour_count"] != 4 || values["hour_sum"] != 16 {
+		t.Fatalf("HOUR fields = %v", values)
+	}
+
+	// 이미 지난 구간의 snapshot은 집계하지 않는다.
+	if out, _ := observe(r, t0.Add(30*time.Minute), gauge(100, "0")); len(out) != 0 {
+		t.Fatalf("emitted %v for a late snapshot", out)
+	}
+}
+
+func TestRollupRestoreAfterRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rollup.json")
+	field := func(string, *dto.Metric) string { return "value" }
+	t0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	r := newRollup(path, field, pkg.DefaultLogger)
+	observe(r, t0, gauge(1, "0"))
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("state was saved before the window ended: %v", err)
+	}
+	// exporter는 구간이 끝났을 때만 저장한다.
+	if _, flushed := observe(r, t0.Add(5*time.Minute), gauge(2, "0")); !flushed {
+		t.Fatal("5MIN window did not end")
+	}
+	observe(r, t0.Add(6*time.Minute), gauge(3, "0"))
+	if err := r.save(); err != nil {
+		t.Fatal(err)
+	}
+
+	restored := newRollup(path, field, pkg.DefaultLogger)
+	out, _ := observe(restored, t0.Add(time.Hour), gauge(4, "0"))
+	values := make(map[string]map[string]float64)
+	for _, e := range out {
+		values[e.period] = fieldValues(e.metric)
+	}
+	if got := values["5MIN"]; got["value_count"] != 2 || got["value_sum"] != 5 {
+		t.Fatalf("restored 5MIN fields = %v, want count 2 and sum 5", got)
+	}
+	if got := values["HOUR"]; got["value_count"] != 3 || got["value_sum"] != 6 || got["value_last"] != 3 {
+		t.Fatalf("restored HOUR fields = %v, want count 3, sum 6 and last 3", got)
+	}
+}
@@ -0,0 +1,84 @@
+package influxdb
+
+import (
+	"strings"
+
+	"github.com/winey-dev/telemetry/metric"
+	"github.com/winey-dev/telemetry/register"
+)
+
+const (
+	selfBucketTag = "bucket"
+)
+
+// selfMetrics는 InfluxDB Exporter의 상태를 나타내는 metric으로 telemetry.agent.* 이름으로 사용자 metric과 함께 기록된다.
+// gather 시간과 Export 실패, 재시도 횟수는 register agent가 기록한다.
+type selfMetrics struct {
+	points              *metric.DeltaCounterVec
+	writeFailures       *metric.CounterVec
+	failedWriteAttempts *metric.CounterVec
+	droppedPoints       *metric.CounterVec
+	replayedBatches     *metric.CounterVec
+	discardedBatches    *metric.CounterVec
+	evictedSegments     *metric.CounterVec
+	corruptRecords      *metric.CounterVec
+	backupBytes         metric.GaugeFunc
+	backupFiles         metric.GaugeFunc
+}
+
+func newSelfMetrics(b *backup) *selfMetrics {
+	return &selfMetrics{
+		points: metric.NewDeltaCounterVec(metric.DeltaCounterOpts(register.SelfOpts("points",
+			"Number of points written to each bucket in the interval.")), selfBucketTag),
+		writeFailures: metric.NewCounterVec(metric.CounterOpts(register.SelfOpts("write_failures",
+			"Number of batches that could not be written after all retries.")), selfBucketTag),
+		failedWriteAttempts: metric.NewCounterVec(metric.CounterOpts(register.SelfOpts("failed_write_attempts",
+			"Number of failed batch write attempts, including attempts that were retried.")), selfBucketTag),
+		droppedPoints: metric.NewCounterVec(metric.CounterOpts(register.SelfOpts("dropped_points",
+			"Number of points dropped because they could not be written or backed up.")), selfBucketTag),
+		replayedBatches: metric.NewCounterVec(metric.CounterOpts(register.SelfOpts("replayed_batches",
+			"Number of backed up batches replayed successfully.")), selfBucketTag),
+		discardedBatches: metric.NewCounterVec(metric.CounterOpts(register.SelfOpts("discarded_batches",
+			"Number of backed up batches discarded because InfluxDB rejected them with a non-retryable error.")), selfBucketTag),
+		evictedSegments: metric.NewCounterVec(metric.CounterOpts(register.SelfOpts("evicted_segments",
+			"Number of backup segments evicted by the size or age limit.")), selfBucketTag),
+		corruptRecords: metric.NewCounterVec(metric.CounterOpts(register.SelfOpts("corrupt_records",
+			"Number of corrupt backup records discarded with the rest of their segment.")), selfBucketTag),
+		backupBytes: metric.NewGaugeFunc(metric.GaugeOpts(register.SelfOpts("backup_bytes",
+			"Total size of the backup queue in BackupDir.")), func() float64 {
+			bytes, _ := b.stats()
+			return float64(bytes)
+		}),
+		backupFiles: metric.NewGaugeFunc(metric.GaugeOpts(register.SelfOpts("backup_files",
+			"Number of backup segment files in BackupDir.")), func() float64 {
+			_, files := b.stats()
+			return float64(files)
+		}),
+	}
+}
+
+func (s *selfMetrics) collectors() []metric.Collector {
+	return []metric.Collector{
+		s.points,
+		s.writeFailures,
+		s.failedWriteAttempts,
+		s.droppedPoints,
+		s.replayedBatches,
+		s.discardedBatches,
+		s.evictedSegments,
+		s.corruptRecords,
+		s.backupBytes,
+		s.backupFiles,
+	}
+}
+
+// countLines는 line protocol batch의 point 개수를 반환한다.
+func countLines(batch string) int {
+	n := 0
+	for _, line := range strings.Split(batch, "\n") {
+		if line != "" {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,12 @@
+package otlp
+
+type Config struct {
+	// URL은 OTLP/HTTP metrics endpoint이다. (예: "http://localhost:4318/v1/metrics")
+	URL             string
+	Headers         map[string]string
+	IntervalSeconds int
+	TimeoutSeconds  int
+	RetryAttempts   int
+	// ServiceName이 설정되면 모든 resource에 service.name 속성으로 추가된다.
+	ServiceName string
+}
@@ -0,0 +1,332 @@
+package otlp
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/winey-dev/telemetry/dto"
+	"github.com/winey-dev/telemetry/metric"
+	"github.com/winey-dev/telemetry/register"
+	colmetricpb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	metricpb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+)
+
+const (
+	scopeVersion = "v1"
+)
+
+// request는 한 주기 동안 수집한 metric을 ExportMetricsServiceRequest로 변환한다.
+//
+//   - resource attributes: ConstraintTags
+//   - scope: Category
+//   - metric name: SubCategory.ItemName
+//   - data point attributes: 동적 TagNames
+type request struct {
+	serviceName string
+	started     time.Time
+	start       time.Time
+	now         time.Time
+
+	resources map[string]*metricpb.ResourceMetrics
+	order     []string
+	scopes    map[string]*metricpb.ScopeMetrics
+	metrics   map[string]*metricpb.Metric
+}
+
+// started는 누적 카운터의 시작 시각, start는 직전 export 시각이다.
+func newRequest(serviceName string, started, start, now time.Time) *request {
+	return &request{
+		serviceName: serviceName,
+		started:     started,
+		start:       start,
+		now:         now,
+		resources:   make(map[string]*metricpb.ResourceMetrics),
+		scopes:      make(map[string]*metricpb.ScopeMetrics),
+		metrics:     make(map[string]*metricpb.Metric),
+	}
+}
+
+// Add는 Write로 기록한 값 하나를 추가한다. TagNames 앞쪽의 ConstraintTags는 resource attributes가 된다.
+func (r *request) Add(value *dto.Metric) error {
+	n := min(value.ConstraintTags, len(value.TagNames), len(value.TagValues))
+	constraintTags := metric.NewConstraintTags(value.TagNames[:n], value.TagValues[:n])
+
+	resourceKey := tagsKey(constraintTags.TagNames, constraintTags.TagValues)
+	resource, ok := r.resources[resourceKey]
+	if !ok {
+		resource = &metricpb.ResourceMetrics{
+			Resource: &resourcepb.Resource{
+				Attributes: r.resourceAttributes(constraintTags),
+			},
+		}
+		r.resources[resourceKey] = resource
+		r.order = append(r.order, resourceKey)
+	}
+
+	scopeKey := resourceKey + "\xff" + value.Category
+	scope, ok := r.scopes[scopeKey]
+	if !ok {
+		scope = &metricpb.ScopeMetrics{
+			Scope: &commonpb.InstrumentationScope{
+				Name:    value.Category,
+				Version: scopeVersion,
+			},
+		}
+		r.scopes[scopeKey] = scope
+		resource.ScopeMetrics = append(resource.ScopeMetrics, scope)
+	}
+
+	if len(value.Fields) > 0 {
+		// field별로 <SubCategory>.<ItemName>.<field> metric을 생성한다.
+		for _, field := range value.Fields {
+			sample := *value
+			sample.Value = field.Value
+			sample.Fields = nil
+			if err := r.add(scope, scopeKey, metricName(value)+"."+field.Name, &sample, n); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return r.add(scope, scopeKey, metricName(value), value, n)
+}
+
+func (r *request) add(scope *metricpb.ScopeMetrics, scopeKey, name string, value *dto.Metric, constraintTags int) error {
+	metricKey := scopeKey + "\xff" + name
+	out, ok := r.metrics[metricKey]
+	if !ok {
+		out = &metricpb.Metric{
+			Name:        name,
+			Description: value.Description,
+		}
+		setMetricData(out, value.Kind)
+		r.metrics[metricKey] = out
+		scope.Metrics = append(scope.Metrics, out)
+	}
+
+	// dto.Metric의 TagNames, TagValues는 ConstraintTags가 앞에 위치한다.
+	attrs := attributes(value.TagNames[constraintTags:], value.TagValues[constraintTags:])
+	start := r.start
+	if value.Kind == dto.KindCounter || value.Kind == dto.KindHistogram {
+		start = r.started
+	}
+	var startUnixNano uint64
+	if !start.IsZero() {
+		startUnixNano = uint64(start.UnixNano())
+	}
+
+	if value.Histogram != nil {
+		data, ok := out.Data.(*metricpb.Metric_Histogram)
+		if !ok {
+			return fmt.Errorf("histogram value for non-histogram metric: %s", name)
+		}
+		data.Histogram.DataPoints = append(data.Histogram.DataPoints, histogramDataPoint(value.Histogram, attrs, startUnixNano, uint64(r.now.UnixNano())))
+		return nil
+	}
+
+	if value.Summary != nil {
+		data, ok := out.Data.(*metricpb.Metric_Summary)
+		if !ok {
+			return fmt.Errorf("summary value for non-summary metric: %s", name)
+		}
+		data.Summary.DataPoints = append(data.Summary.DataPoints, summaryDataPoint(value.Summary, attrs, startUnixNano, uint64(r.now.UnixNano())))
+		return nil
+	}
+
+	if value.Aggregation != nil {
+		data, ok := out.Data.(*metricpb.Metric_Summary)
+		if !ok {
+			return fmt.Errorf("aggregation value for non-summary metric: %s", name)
+		}
+		data.Summary.DataPoints = append(data.Summary.DataPoints, aggregationDataPoint(value.Aggregation, attrs, startUnixNano, uint64(r.now.UnixNano())))
+		return nil
+	}
+
+	point := &metricpb.NumberDataPoint{
+		Attributes:        attrs,
+		StartTimeUnixNano: startUnixNano,
+		TimeUnixNano:      uint64(r.now.UnixNano()),
+	}
+	setNumberValue(point, value)
+	switch data := out.Data.(type) {
+	case *metricpb.Metric_Sum:
+		data.Sum.DataPoints = append(data.Sum.DataPoints, point)
+	case *metricpb.Metric_Gauge:
+		data.Gauge.DataPoints = append(data.Gauge.DataPoints, point)
+	}
+	return nil
+}
+
+// setMetricData는 dto.Kind에 맞는 OTLP 데이터 타입을 설정한다.
+// Counter와 DeltaCounter는 단조 증가 Sum으로, 나머지는 Gauge로 변환한다.
+func setMetricData(out *metricpb.Metric, kind dto.Kind) {
+	switch kind {
+	case dto.KindCounter:
+		out.Data = &metricpb.Metric_Sum{Sum: &metricpb.Sum{
+			AggregationTemporality: metricpb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE,
+			IsMonotonic:            true,
+		}}
+	case dto.KindDeltaCounter:
+		out.Data = &metricpb.Metric_Sum{Sum: &metricpb.Sum{
+			AggregationTemporality: metricpb.AggregationTemporality_AGGREGATION_TEMPORALITY_DELTA,
+			IsMonotonic:            true,
+		}}
+	case dto.KindHistogram:
+		out.Data = &metricpb.Metric_Histogram{Histogram: &metricpb.Histogram{
+			AggregationTemporality: metricpb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE,
+		}}
+	case dto.KindSummary, dto.KindAvg:
+		out.Data = &metricpb.Metric_Summary{Summary: &metricpb.Summary{}}
+	default:
+		out.Data = &metricpb.Metric_Gauge{Gauge: &metricpb.Gauge{}}
+	}
+}
+
+func summaryDataPoint(s *dto.Summary, attrs []*commonpb.KeyValue, start, now uint64) *metricpb.SummaryDataPoint {
+	values := make([]*metricpb.SummaryDataPoint_ValueAtQuantile, len(s.Quantiles))
+	for i, q := range s.Quantiles {
+		values[i] = &metricpb.SummaryDataPoint_ValueAtQuantile{Quantile: q.Quantile, Value: q.Value}
+	}
+	return &metricpb.SummaryDataPoint{
+		Attributes:        attrs,
+		StartTimeUnixNano: start,
+		TimeUnixNano:      now,
+		Count:             s.Count,
+		Sum:               s.Sum,
+		QuantileValues:    values,
+	}
+}
+
+// histogramDataPoint는 누적 버킷 개수를 OTLP의 구간별 개수로 변환한다.
+func histogramDataPoint(h *dto.Histogram, attrs []*commonpb.KeyValue, start, now uint64) *metricpb.HistogramDataPoint {
+	bounds := make([]float64, len(h.Buckets))
+	counts := make([]uint64, len(h.Buckets)+1)
+	var prev uint64
+	for i, bucket := range h.Buckets {
+		bounds[i] = bucket.UpperBound
+		counts[i] = bucket.Count - prev
+		prev = bucket.Count
+	}
+	counts[len(h.Buckets)] = h.Count - prev
+
+	sum := h.Sum
+	return &metricpb.HistogramDataPoint{
+		Attributes:        attrs,
+		StartTimeUnixNano: start,
+		TimeUnixNano:      now,
+		Count:             h.Count,
+		Sum:               &sum,
+		BucketCounts:      counts,
+		ExplicitBounds:    bounds,
+	}
+}
+
+func (r *request) Len() int {
+	return len(r.metrics)
+}
+
+func (r *request) Build() *colmetricpb.ExportMetricsServiceRequest {
+	req := &colmetricpb.ExportMetricsServiceRequest{
+		ResourceMetrics: make([]*metricpb.ResourceMetrics, 0, len(r.order)),
+	}
+	for _, key := range r.order {
+		req.ResourceMetrics = append(req.ResourceMetrics, r.resources[key])
+	}
+	return req
+}
+
+func (r *request) resourceAttributes(tags metric.ConstraintTags) []*commonpb.KeyValue {
+	attrs := attributes(tags.TagNames, tags.TagValues)
+	if r.serviceName != "" {
+		attrs = append(attrs, stringAttribute("service.name", r.serviceName))
+	}
+	return attrs
+}
+
+func metricName(m *dto.Metric) string {
+	if m.SubCategory == "" {
+		return m.ItemName
+	}
+	return m.SubCategory + "." + m.ItemName
+}
+
+func attributes(names, values []string) []*commonpb.KeyValue {
+	if len(names) == 0 {
+		return nil
+	}
+	attrs := make([]*commonpb.KeyValue, 0, len(names))
+	for i, name := range names {
+		if i >= len(values) {
+			break
+		}
+		attrs = append(attrs, stringAttribute(name, values[i]))
+	}
+	return attrs
+}
+
+func stringAttribute(key, value string) *commonpb.KeyValue {
+	return &commonpb.KeyValue{
+		Key:   key,
+		Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: value}},
+	}
+}
+
+func tagsKey(names, values []string) string {
+	var b strings.Builder
+	for i, name := range names {
+		b.WriteString(name)
+		b.WriteByte('=')
+		if i < len(values) {
+			b.WriteString(values[i])
+		}
+		b.WriteByte('\xff')
+	}
+	return b.String()
+}
+
+// aggregationDataPoint는 AvgItem 값을 Summary로 변환한다. 최소, 최대 값은 0, 1 분위수로 표현한다.
+func aggregationDataPoint(a *dto.Aggregation, attrs []*commonpb.KeyValue, start, now uint64) *metricpb.SummaryDataPoint {
+	point := &metricpb.SummaryDataPoint{
+		Attributes:        attrs,
+		StartTimeUnixNano: start,
+		TimeUnixNano:      now,
+		Count:             a.Count,
+		Sum:               a.Sum,
+	}
+	if a.Count > 0 {
+		point.QuantileValues = []*metricpb.SummaryDataPoint_ValueAtQuantile{
+			{Quantile: 0, Value: a.Min},
+			{Quantile: 1, Value: a.Max},
+		}
+	}
+	return point
+}
+
+// setNumberValue는 정수, bool 값을 AsInt로 기록한다. 문자열 값은 value 속성으로 기록하고 값은 1로 고정한다.
+func setNumberValue(point *metricpb.NumberDataPoint, value *dto.Metric) {
+	switch value.ValueType {
+	case dto.ValueInt:
+		point.Value = &metricpb.NumberDataPoint_AsInt{AsInt: value.IntValue}
+	case dto.ValueUint:
+		if value.UintValue > math.MaxInt64 {
+			point.Value = &metricpb.NumberDataPoint_AsDouble{AsDouble: float64(value.UintValue)}
+			return
+		}
+		point.Value = &metricpb.NumberDataPoint_AsInt{AsInt: int64(value.UintValue)}
+	case dto.ValueBool:
+		var v int64
+		if value.BoolValue {
+			v = 1
+		}
+		point.Value = &metricpb.NumberDataPoint_AsInt{AsInt: v}
+	case dto.ValueString:
+		point.Attributes = append(point.Attributes, stringAttribute(register.StringValueTagName, value.StringValue))
+		point.Value = &metricpb.NumberDataPoint_AsInt{AsInt: 1}
+	default:
+		point.Value = &metricpb.NumberDataPoint_AsDouble{AsDouble: value.Value}
+	}
+}
@@ -0,0 +1,176 @@
+package otlp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/winey-dev/telemetry/dto"
+	"github.com/winey-dev/telemetry/pkg"
+	"github.com/winey-dev/telemetry/register"
+	colmetricpb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	contentType       = "application/x-protobuf"
+	defaultTimeout    = 10 * time.Second
+	maxResponseLength = 64 << 10
+)
+
+// exporter는 snapshot을 ExportMetricsServiceRequest로 변환하여 OTLP/HTTP receiver로 전송한다.
+// 재시도는 register agent가 담당하며 receiver가 보낸 Retry-After와 재시도할 수 없는 응답은 ExportError로 전달한다.
+type exporter struct {
+	client *http.Client
+	config *Config
+
+	// started는 누적 카운터의 시작 시각이다. start는 직전 snapshot의 시각이며 같은 snapshot의 재시도에는 바뀌지 않는다.
+	mtx     sync.Mutex
+	started time.Time
+	start   time.Time
+	now     time.Time
+
+	logger pkg.Logger
+}
+
+// NewRegisterer는 OTLP Exporter 하나를 가진 Agent를 생성한다.
+func NewRegisterer(config *Config) (register.Agent, error) {
+	exporter, err := NewExporter(config)
+	if err != nil {
+		return nil, err
+	}
+	return register.NewAgent(&register.AgentConfig{
+		IntervalSeconds: config.IntervalSeconds,
+		RetryAttempts:   config.RetryAttempts,
+		TimeoutSeconds:  config.TimeoutSeconds,
+	}, exporter)
+}
+
+// NewExporter는 register.NewAgent에 다른 Exporter와 함께 사용할 수 있는 OTLP Exporter를 생성한다.
+func NewExporter(config *Config) (register.Exporter, error) {
+	if config.URL == "" {
+		return nil, fmt.Errorf("otlp endpoint url is required")
+	}
+	timeout := defaultTimeout
+	if config.TimeoutSeconds > 0 {
+		timeout = time.Duration(config.TimeoutSeconds) * time.Second
+	}
+
+	now := time.Now()
+	return &exporter{
+		client:  &http.Client{Timeout: timeout},
+		config:  config,
+		started: now,
+		now:     now,
+		logger:  pkg.DefaultLogger,
+	}, nil
+}
+
+func (e *exporter) String() string {
+	return "otlp"
+}
+
+func (e *exporter) Close() {
+	e.client.CloseIdleConnections()
+}
+
+// Start는 누적 카운터의 시작 시각을 agent의 시작 시각으로 설정한다.
+func (e *exporter) Start(context.Context) error {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+	e.started = time.Now()
+	e.now = e.started
+	return nil
+}
+
+func (e *exporter) Export(ctx context.Context, metrics []dto.Metric, now time.Time) error {
+	e.mtx.Lock()
+	if !now.Equal(e.now) {
+		e.start, e.now = e.now, now
+	}
+	req := newRequest(e.config.ServiceName, e.started, e.start, now)
+	e.mtx.Unlock()
+
+	for i := range metrics {
+		if err := req.Add(&metrics[i]); err != nil {
+			e.logger.Error("Failed to convert metric(%s.%s.%s): %v", metrics[i].Category, metrics[i].SubCategory, metrics[i].ItemName, err)
+		}
+	}
+	if req.Len() == 0 {
+		return nil
+	}
+	return e.export(ctx, req.Build())
+}
+
+// export는 request를 한 번 전송한다. 실패하면 receiver의 응답에 따라 재시도 방법을 담은 ExportError를 반환한다.
+func (e *exporter) export(ctx context.Context, req *colmetricpb.ExportMetricsServiceRequest) error {
+	body, err := proto.Marshal(req)
+	if err != nil {
+		return &register.ExportError{Err: err, Permanent: true}
+	}
+	retryAfter, err := e.post(ctx, body)
+	if err == nil {
+		return nil
+	}
+	if retryAfter < 0 {
+		return &register.ExportError{Err: err, Permanent: true}
+	}
+	return &register.ExportError{Err: err, RetryAfter: retryAfter}
+}
+
+// post는 body를 한 번 전송한다. 실패하면 receiver가 요청한 대기 시간을 반환하며,
+// 대기 시간 없이 재시도할 수 있으면 0, 재시도하면 안 되면 -1을 반환한다.
+func (e *exporter) post(ctx context.Context, body []byte) (time.Duration, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, e.config.URL, bytes.NewReader(body))
+	if err != nil {
+		return -1, err
+	}
+	httpReq.Header.Set("Content-Type", contentType)
+	for key, value := range e.config.Headers {
+		httpReq.Header.Set(key, value)
+	}
+
+	resp, err := e.client.Do(httpReq)
+	if err != nil {
+		// agent가 종료되어 취소된 경우만 재시도하지 않는다. 시도별 timeout(DeadlineExceeded)은 재시도한다.
+		if errors.Is(ctx.Err(), context.Canceled) {
+			return -1, err
+		}
+		return 0, err
+	}
+	defer resp.Body.Close()
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseLength))
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return 0, nil
+	}
+	err = fmt.Errorf("otlp receiver responded %s: %s", resp.Status, bytes.TrimSpace(msg))
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()), err
+	}
+	return -1, err
+}
+
+// parseRetryAfter는 초 단위 값과 HTTP-date 형식의 값을 모두 지원한다.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+	return 0
+}
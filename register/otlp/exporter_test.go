@@ -0,0 +1,353 @@
+package otlp
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/winey-dev/telemetry/dto"
+	"github.com/winey-dev/telemetry/metric"
+	"github.com/winey-dev/telemetry/register"
+	colmetricpb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	metricpb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// newReceiver는 수신한 ExportMetricsServiceRequest를 ch로 전달하는 OTLP/HTTP receiver이다.
+func newReceiver(t *testing.T, ch chan<- *colmetricpb.ExportMetricsServiceRequest) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != contentType {
+			http.Error(w, "unexpected content type", http.StatusUnsupportedMediaType)
+			return
+		}
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		req := &colmetricpb.ExportMetricsServiceRequest{}
+		if err := proto.Unmarshal(body, req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		select {
+		case ch <- req:
+		default:
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func attributeMap(attrs []*commonpb.KeyValue) map[string]string {
+	out := make(map[string]string, len(attrs))
+	for _, attr := range attrs {
+		out[attr.Key] = attr.Value.GetStringValue()
+	}
+	return out
+}
+
+func TestAgentExportsToReceiver(t *testing.T) {
+	ch := make(chan *colmetricpb.ExportMetricsServiceRequest, 1)
+	server := newReceiver(t, ch)
+
+	agent, err := NewRegisterer(&Config{
+		URL:             server.URL,
+		IntervalSeconds: 1,
+		ServiceName:     "gateway",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	vec := metric.NewCounterVec(metric.CounterOpts{
+		Category:       "network",
+		SubCategory:    "interface",
+		ItemName:       "rx_bytes",
+		ConstraintTags: metric.NewConstraintTags([]string{"host"}, []string{"h1"}),
+	}, "interface")
+	if err := agent.Register(vec); err != nil {
+		t.Fatal(err)
+	}
+	vec.WithTagValues("eth0").Add(10)
+
+	if err := agent.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer agent.Stop()
+
+	var req *colmetricpb.ExportMetricsServiceRequest
+	select {
+	case req = <-ch:
+	case <-time.After(5 * time.Second):
+		t.Fatal("receiver got no request")
+	}
+
+	// agent의 self metric은 ConstraintTags가 없는 별도의 resource로 전송된다.
+	var resource *metricpb.ResourceMetrics
+	for _, rm := range req.ResourceMetrics {
+		if attributeMap(rm.Resource.Attributes)["host"] == "h1" {
+			resource = rm
+		}
+	}
+	if resource == nil {
+		t.Fatalf("no resource with host=h1 in %v", req.ResourceMetrics)
+	}
+	if attrs := attributeMap(resource.Resource.Attributes); len(attrs) != 2 || attrs["service.name"] != "gateway" {
+		t.Fatalf("resource attributes = %v", attrs)
+	}
+	if len(resource.ScopeMetrics) != 1 || resource.ScopeMetrics[0].Scope.Name != "network" {
+		t.Fatalf("scopes = %v", resource.ScopeMetrics)
+	}
+	metrics := resource.ScopeMetrics[0].Metrics
+	if len(metrics) != 1 || metrics[0].Name != "interface.rx_bytes" {
+		t.Fatalf("metrics = %v", metrics)
+	}
+	sum := metrics[0].GetSum()
+	if sum == nil || !sum.IsMonotonic || len(sum.DataPoints) != 1 {
+		t.Fatalf("sum = %v", sum)
+	}
+	point := sum.DataPoints[0]
+	if attrs := attributeMap(point.Attributes); len(attrs) != 1 || attrs["interface"] != "eth0" {
+		t.Fatalf("data point attributes = %v", attrs)
+	}
+	if point.GetAsDouble() != 10 {
+		t.Fatalf("value = %v, want 10", point.GetAsDouble())
+	}
+}
+
+func TestAgentHonoursRetryAfter(t *testing.T) {
+	var calls atomic.Int32
+	var first, second atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch calls.Add(1) {
+		case 1:
+			first.Store(time.Now().UnixNano())
+			w.Header().Set("Retry-After", "2")
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case 2:
+			second.Store(time.Now().UnixNano())
+			w.WriteHeader(http.StatusOK)
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer server.Close()
+
+	agent, err := NewRegisterer(&Config{URL: server.URL, IntervalSeconds: 1, RetryAttempts: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := agent.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer agent.Stop()
+
+	deadline := time.Now().Add(10 * time.Second)
+	for second.Load() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("export was not retried")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	// backoff의 첫 대기 시간은 1초 미만이므로 2초 이상이면 Retry-After를 따른 것이다.
+	if gap := time.Duration(second.Load() - first.Load()); gap < 2*time.Second {
+		t.Fatalf("retried after %s, want at least 2s", gap)
+	}
+}
+
+func TestExportClassifiesFailures(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		retryAfter string
+		want       register.ExportError
+	}{
+		{"retry after", http.StatusServiceUnavailable, "1", register.ExportError{RetryAfter: time.Second}},
+		{"retryable without hint", http.StatusTooManyRequests, "", register.ExportError{}},
+		{"client error", http.StatusBadRequest, "", register.ExportError{Permanent: true}},
+		{"server error", http.StatusInternalServerError, "1", register.ExportError{Permanent: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.retryAfter != "" {
+					w.Header().Set("Retry-After", tt.retryAfter)
+				}
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			e, err := NewExporter(&Config{URL: server.URL})
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = e.Export(context.Background(), []dto.Metric{{Category: "c", SubCategory: "s", ItemName: "i", Value: 1}}, time.Now())
+			var exportErr *register.ExportError
+			if !errors.As(err, &exportErr) {
+				t.Fatalf("err = %v, want *register.ExportError", err)
+			}
+			if exportErr.RetryAfter != tt.want.RetryAfter || exportErr.Permanent != tt.want.Permanent {
+				t.Fatalf("got RetryAfter %s, Permanent %t, want %s, %t", exportErr.RetryAfter, exportErr.Permanent, tt.want.RetryAfter, tt.want.Permanent)
+			}
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"3", 3 * time.Second},
+		{"-1", 0},
+		{now.Add(5 * time.Second).Format(http.TimeFormat), 5 * time.Second},
+		{now.Add(-5 * time.Second).Format(http.TimeFormat), 0},
+		{"soon", 0},
+	}
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.value, now); got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestRequestSplitsInvalidConstraintTags(t *testing.T) {
+	constraintTags := metric.NewConstraintTags([]string{"host"}, []string{""})
+	if constraintTags.Len() != 0 {
+		t.Fatalf("Len() = %d, want 0 for invalid constraint tags", constraintTags.Len())
+	}
+	c := metric.NewGaugeVec(metric.GaugeOpts{
+		Category:       "network",
+		SubCategory:    "interface",
+		ItemName:       "up",
+		ConstraintTags: constraintTags,
+	}, "interface")
+	c.WithTagValues("eth0").Set(1)
+
+	now := time.Now()
+	req := newRequest("", now, now, now)
+	for _, m := range register.CollectAll(c) {
+		var value dto.Metric
+		if err := m.Write(&value); err != nil {
+			t.Fatal(err)
+		}
+		if err := req.Add(&value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	out := req.Build()
+	point := out.ResourceMetrics[0].ScopeMetrics[0].Metrics[0].GetGauge().DataPoints[0]
+	if attrs := attributeMap(point.Attributes); len(attrs) != 1 || attrs["interface"] != "eth0" {
+		t.Fatalf("data point attributes = %v", attrs)
+	}
+}
+
+// pointTime은 request의 첫 data point 시각이다. 같은 snapshot의 재시도는 같은 시각을 갖는다.
+func pointTime(req *colmetricpb.ExportMetricsServiceRequest) uint64 {
+	for _, rm := range req.ResourceMetrics {
+		for _, sm := range rm.ScopeMetrics {
+			for _, m := range sm.Metrics {
+				if gauge := m.GetGauge(); gauge != nil && len(gauge.DataPoints) > 0 {
+					return gauge.DataPoints[0].TimeUnixNano
+				}
+				if sum := m.GetSum(); sum != nil && len(sum.DataPoints) > 0 {
+					return sum.DataPoints[0].TimeUnixNano
+				}
+			}
+		}
+	}
+	return 0
+}
+
+func TestAgentRetriesTimedOutExport(t *testing.T) {
+	var calls atomic.Int32
+	times := make(chan uint64, 8)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		req := &colmetricpb.ExportMetricsServiceRequest{}
+		if err := proto.Unmarshal(body, req); err == nil {
+			select {
+			case times <- pointTime(req):
+			default:
+			}
+		}
+		// 첫 요청은 agent의 timeout이 지날 때까지 응답하지 않는다.
+		if calls.Add(1) == 1 {
+			<-r.Context().Done()
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	agent, err := NewRegisterer(&Config{URL: server.URL, IntervalSeconds: 1, TimeoutSeconds: 1, RetryAttempts: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	gauge := metric.NewGauge(metric.GaugeOpts{Category: "c", SubCategory: "s", ItemName: "i"})
+	gauge.Set(1)
+	if err := agent.Register(gauge); err != nil {
+		t.Fatal(err)
+	}
+	if err := agent.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer agent.Stop()
+
+	var first, second uint64
+	for _, got := range []*uint64{&first, &second} {
+		select {
+		case *got = <-times:
+		case <-time.After(10 * time.Second):
+			t.Fatalf("receiver got %d requests, want a retry of the timed out request", calls.Load())
+		}
+	}
+	if first == 0 || first != second {
+		t.Fatalf("second request is snapshot %d, want a retry of snapshot %d", second, first)
+	}
+}
+
+func TestExportClassifiesContextErrors(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	e, err := NewExporter(&Config{URL: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	metrics := []dto.Metric{{Category: "c", SubCategory: "s", ItemName: "i", Value: 1}}
+
+	// 시도별 timeout은 재시도한다.
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	err = e.Export(ctx, metrics, time.Now())
+	cancel()
+	var exportErr *register.ExportError
+	if !errors.As(err, &exportErr) || exportErr.Permanent {
+		t.Fatalf("timed out export: err = %v, want a retryable ExportError", err)
+	}
+
+	// agent가 종료되어 취소되면 재시도하지 않는다.
+	ctx, cancel = context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+	err = e.Export(ctx, metrics, time.Now())
+	if !errors.As(err, &exportErr) || !exportErr.Permanent {
+		t.Fatalf("canceled export: err = %v, want a permanent ExportError", err)
+	}
+}
@@ -0,0 +1,101 @@
+package prometheus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/winey-dev/telemetry/pkg"
+	"github.com/winey-dev/telemetry/register"
+)
+
+const (
+	defaultPath     = "/metrics"
+	defaultInterval = time.Minute
+)
+
+type agent struct {
+	register.Registry
+	config *Config
+	server *http.Server
+	done   chan struct{}
+	wg     sync.WaitGroup
+
+	logger pkg.Logger
+}
+
+func NewRegisterer(config *Config) (register.Agent, error) {
+	if config.Addr == "" {
+		return nil, fmt.Errorf("prometheus listen address is required")
+	}
+	return &agent{
+		config: config,
+		logger: pkg.DefaultLogger,
+	}, nil
+}
+
+func (a *agent) Start() error {
+	path := a.config.Path
+	if path == "" {
+		path = defaultPath
+	}
+
+	listener, err := net.Listen("tcp", a.config.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", a.config.Addr, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(path, NewHandler(&a.Registry, a.logger))
+	a.server = &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	a.done = make(chan struct{})
+	a.wg.Add(2)
+	go func() {
+		defer a.wg.Done()
+		if err := a.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			a.logger.Error("Prometheus endpoint stopped(%s): %v", a.config.Addr, err)
+		}
+	}()
+	go a.tickLoop()
+	return nil
+}
+
+// tickLoop는 scrape와 관계없이 주기마다 한 번 Registry.Tick을 호출한다.
+func (a *agent) tickLoop() {
+	defer a.wg.Done()
+	interval := defaultInterval
+	if a.config.IntervalSeconds > 0 {
+		interval = time.Duration(a.config.IntervalSeconds) * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-a.done:
+			return
+		case <-ticker.C:
+			a.Tick()
+		}
+	}
+}
+
+func (a *agent) Stop() {
+	if a.server == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := a.server.Shutdown(ctx); err != nil {
+		a.logger.Error("Failed to shutdown prometheus endpoint(%s): %v", a.config.Addr, err)
+	}
+	close(a.done)
+	a.wg.Wait()
+}
@@ -0,0 +1,10 @@
+package prometheus
+
+type Config struct {
+	// Addr는 scrape endpoint를 노출할 주소이다. (예: ":9100")
+	Addr string
+	// Path는 scrape endpoint 경로이다. 비어있으면 "/metrics"를 사용한다.
+	Path string
+	// IntervalSeconds는 series TTL과 series 수 제한 경고의 주기(Registry.Tick)이다. 0이면 60초를 사용한다.
+	IntervalSeconds int
+}
@@ -0,0 +1,275 @@
+package prometheus
+
+import (
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/winey-dev/telemetry/dto"
+	"github.com/winey-dev/telemetry/metric"
+	"github.com/winey-dev/telemetry/register"
+)
+
+type family struct {
+	name    string
+	help    string
+	kind    dto.Kind
+	samples []*dto.Metric
+}
+
+// encode는 metrics를 이름별로 묶어 Prometheus text exposition format으로 기록한다.
+// Write는 값을 0으로 초기화하기 때문에 metric.Reader를 구현한 경우 Read를 사용한다.
+func encode(w io.Writer, metrics []metric.Metric) error {
+	var errs []error
+	families := make(map[string]*family)
+	for _, m := range metrics {
+		var out dto.Metric
+		var err error
+		if reader, ok := m.(metric.Reader); ok {
+			err = reader.Read(&out)
+		} else {
+			err = m.Write(&out)
+		}
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		name := metricName(&out)
+		if out.Aggregation != nil {
+			addAggregation(families, name, &out)
+			continue
+		}
+		if len(out.Fields) > 0 {
+			addFields(families, name, &out)
+			continue
+		}
+		addSample(families, name, &out)
+	}
+
+	names := make([]string, 0, len(families))
+	for name := range families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		f := families[name]
+		if f.help != "" {
+			b.WriteString("# HELP ")
+			b.WriteString(name)
+			b.WriteByte(' ')
+			b.WriteString(escapeHelp(f.help))
+			b.WriteByte('\n')
+		}
+		b.WriteString("# TYPE ")
+		b.WriteString(name)
+		b.WriteByte(' ')
+		b.WriteString(typeName(f.kind))
+		b.WriteByte('\n')
+		for _, sample := range f.samples {
+			if sample.Histogram != nil {
+				writeHistogram(&b, name, sample)
+				continue
+			}
+			if sample.Summary != nil {
+				writeSummary(&b, name, sample)
+				continue
+			}
+			if sample.ValueType == dto.ValueString {
+				// 문자열 값은 value 태그로 기록하고 값은 1로 고정한다. value는 Register 시점에 예약되어 있다.
+				writeSample(&b, name, sample.TagNames, sample.TagValues, register.StringValueTagName, sample.StringValue, "1")
+				continue
+			}
+			writeSample(&b, name, sample.TagNames, sample.TagValues, "", "", formatValue(sample))
+		}
+	}
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return err
+	}
+	if len(errs) > 0 {
+		return errs[0]
+	}
+	return nil
+}
+
+func addSample(families map[string]*family, name string, m *dto.Metric) {
+	f, ok := families[name]
+	if !ok {
+		f = &family{name: name, help: m.Description, kind: m.Kind}
+		families[name] = f
+	}
+	f.samples = append(f.samples, m)
+}
+
+// addAggregation은 AvgItem의 값을 <name>_mean, _min, _max, _sum, _count gauge로 나누어 추가한다.
+func addAggregation(families map[string]*family, name string, m *dto.Metric) {
+	a := m.Aggregation
+	values := []struct {
+		suffix string
+		value  float64
+	}{
+		{"mean", a.Mean},
+		{"min", a.Min},
+		{"max", a.Max},
+		{"sum", a.Sum},
+		{"count", float64(a.Count)},
+	}
+	for _, v := range values {
+		if a.Count == 0 && v.suffix != "sum" && v.suffix != "count" {
+			continue
+		}
+		sample := *m
+		sample.Kind = dto.KindGauge
+		sample.Value = v.value
+		sample.Aggregation = nil
+		addSample(families, name+"_"+v.suffix, &sample)
+	}
+}
+
+// addFields는 FieldsItem의 각 field를 <name>_<field> metric으로 나누어 추가한다.
+func addFields(families map[string]*family, name string, m *dto.Metric) {
+	for _, field := range m.Fields {
+		sample := *m
+		sample.Value = field.Value
+		sample.Fields = nil
+		addSample(families, name+"_"+sanitizeName(field.Name, true), &sample)
+	}
+}
+
+func writeHistogram(b *strings.Builder, name string, m *dto.Metric) {
+	for _, bucket := range m.Histogram.Buckets {
+		writeSample(b, name+"_bucket", m.TagNames, m.TagValues, "le", formatFloat(bucket.UpperBound), strconv.FormatUint(bucket.Count, 10))
+	}
+	writeSample(b, name+"_bucket", m.TagNames, m.TagValues, "le", "+Inf", strconv.FormatUint(m.Histogram.Count, 10))
+	writeSample(b, name+"_sum", m.TagNames, m.TagValues, "", "", formatFloat(m.Histogram.Sum))
+	writeSample(b, name+"_count", m.TagNames, m.TagValues, "", "", strconv.FormatUint(m.Histogram.Count, 10))
+}
+
+func writeSummary(b *strings.Builder, name string, m *dto.Metric) {
+	for _, q := range m.Summary.Quantiles {
+		writeSample(b, name, m.TagNames, m.TagValues, "quantile", formatFloat(q.Quantile), formatFloat(q.Value))
+	}
+	writeSample(b, name+"_sum", m.TagNames, m.TagValues, "", "", formatFloat(m.Summary.Sum))
+	writeSample(b, name+"_count", m.TagNames, m.TagValues, "", "", strconv.FormatUint(m.Summary.Count, 10))
+}
+
+// writeSample은 한 줄의 sample을 기록한다. extraName이 비어있지 않으면 마지막 태그로 추가된다.
+func writeSample(b *strings.Builder, name string, tagNames, tagValues []string, extraName, extraValue, value string) {
+	b.WriteString(name)
+	if len(tagNames) > 0 || extraName != "" {
+		b.WriteByte('{')
+		for i, tagName := range tagNames {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			b.WriteString(sanitizeName(tagName, false))
+			b.WriteString(`="`)
+			if i < len(tagValues) {
+				b.WriteString(escapeTagValue(tagValues[i]))
+			}
+			b.WriteByte('"')
+		}
+		if extraName != "" {
+			if len(tagNames) > 0 {
+				b.WriteByte(',')
+			}
+			b.WriteString(extraName)
+			b.WriteString(`="`)
+			b.WriteString(escapeTagValue(extraValue))
+			b.WriteByte('"')
+		}
+		b.WriteByte('}')
+	}
+	b.WriteByte(' ')
+	b.WriteString(value)
+	b.WriteByte('\n')
+}
+
+// typeName은 dto.Kind를 exposition format의 TYPE으로 변환한다.
+// DeltaCounter는 scrape 간격과 초기화 주기가 일치하지 않기 때문에 untyped로 노출한다.
+func typeName(kind dto.Kind) string {
+	switch kind {
+	case dto.KindCounter:
+		return "counter"
+	case dto.KindGauge:
+		return "gauge"
+	case dto.KindHistogram:
+		return "histogram"
+	case dto.KindSummary:
+		return "summary"
+	default:
+		return "untyped"
+	}
+}
+
+// metricName은 Category_SubCategory_ItemName 형식의 이름을 생성한다.
+func metricName(m *dto.Metric) string {
+	parts := make([]string, 0, 3)
+	for _, part := range []string{m.Category, m.SubCategory, m.ItemName} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return sanitizeName(strings.Join(parts, "_"), true)
+}
+
+// sanitizeName은 metric, 태그 이름에 사용할 수 없는 문자를 '_'로 바꾼다. 숫자로 시작하면 앞에 '_'를 붙인다.
+// ':'는 metric 이름에만 사용할 수 있다.
+func sanitizeName(name string, allowColon bool) string {
+	var b strings.Builder
+	b.Grow(len(name))
+	for i, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r == '_', r == ':' && allowColon:
+			b.WriteRune(r)
+		case r >= '0' && r <= '9':
+			if i == 0 {
+				b.WriteByte('_')
+			}
+			b.WriteRune(r)
+		default:
+			b.WriteByte('_')
+		}
+	}
+	return b.String()
+}
+
+var (
+	helpEscaper     = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	tagValueEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string     { return helpEscaper.Replace(s) }
+func escapeTagValue(s string) string { return tagValueEscaper.Replace(s) }
+
+// formatValue는 정수 값을 float64로 변환하지 않고 그대로 기록한다.
+func formatValue(m *dto.Metric) string {
+	switch m.ValueType {
+	case dto.ValueInt:
+		return strconv.FormatInt(m.IntValue, 10)
+	case dto.ValueUint:
+		return strconv.FormatUint(m.UintValue, 10)
+	case dto.ValueBool:
+		if m.BoolValue {
+			return "1"
+		}
+		return "0"
+	}
+	return formatFloat(m.Value)
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsNaN(v):
+		return "NaN"
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
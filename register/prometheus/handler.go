@@ -0,0 +1,63 @@
+package prometheus
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/winey-dev/telemetry/pkg"
+	"github.com/winey-dev/telemetry/register"
+)
+
+const (
+	contentType = "text/plain; version=0.0.4; charset=utf-8"
+)
+
+type handler struct {
+	gatherer register.Gatherer
+	logger   pkg.Logger
+}
+
+// NewHandler는 요청마다 gatherer.Gather를 호출하여 text exposition format으로 응답하는 http.Handler를 생성한다.
+// scrape 횟수는 scraper의 수에 따라 달라지므로 Registry.Tick은 호출하지 않는다. agent 없이 사용하면 Tick을 주기적으로 호출한다.
+func NewHandler(gatherer register.Gatherer, logger pkg.Logger) http.Handler {
+	if logger == nil {
+		logger = pkg.DefaultLogger
+	}
+	return &handler{gatherer: gatherer, logger: logger}
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if timeout := scrapeTimeout(r); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	metrics, err := h.gatherer.Gather(ctx)
+	if err != nil {
+		h.logger.Error("Failed to gather metrics: %v", err)
+		if len(metrics) == 0 {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := encode(&buf, metrics); err != nil {
+		h.logger.Error("Failed to encode metrics: %v", err)
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Write(buf.Bytes())
+}
+
+// scrapeTimeout은 Prometheus가 전달하는 X-Prometheus-Scrape-Timeout-Seconds 헤더 값을 반환한다.
+func scrapeTimeout(r *http.Request) time.Duration {
+	seconds, err := strconv.ParseFloat(r.Header.Get("X-Prometheus-Scrape-Timeout-Seconds"), 64)
+	if err != nil || seconds <= 0 {
+		return 0
+	}
+	return time.Duration(seconds * float64(time.Second))
+}
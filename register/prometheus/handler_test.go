@@ -0,0 +1,97 @@
+package prometheus
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/winey-dev/telemetry/metric"
+	"github.com/winey-dev/telemetry/register"
+)
+
+// scrape는 handler에 한 번 요청하고 응답 body를 반환한다.
+func scrape(t *testing.T, handler http.Handler) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+	}
+	if got := rec.Header().Get("Content-Type"); got != contentType {
+		t.Fatalf("Content-Type = %q", got)
+	}
+	return rec.Body.String()
+}
+
+func TestHandlerScrapesWithoutReset(t *testing.T) {
+	var registry register.Registry
+
+	gauge := metric.NewGaugeVec(metric.GaugeOpts{Category: "net", SubCategory: "if", ItemName: "up", Description: "Link state."}, "interface")
+	gauge.WithTagValues("eth\"0\\\n").Set(1)
+	delta := metric.NewDeltaCounter(metric.DeltaCounterOpts{Category: "cpu", SubCategory: "core", ItemName: "ticks", Description: "Ticks \\ per\ncore."})
+	delta.Add(3)
+	avg := metric.NewAvgItem(metric.ItemOpts{Category: "disk", SubCategory: "io", ItemName: "latency"})
+	avg.Observe(1)
+	avg.Observe(3)
+	summary := metric.NewSummary(metric.SummaryOpts{Category: "http", SubCategory: "req", ItemName: "duration", Objectives: []float64{0.5}})
+	for _, v := range []float64{1, 2, 3} {
+		summary.Observe(v)
+	}
+	sanitized := metric.NewGaugeVec(metric.GaugeOpts{Category: "1st", SubCategory: "http-server", ItemName: "req.count"}, "status-code")
+	sanitized.WithTagValues("200").Set(5)
+	if err := registry.Registers(gauge, delta, avg, summary, sanitized); err != nil {
+		t.Fatal(err)
+	}
+
+	handler := NewHandler(&registry, nil)
+	first := scrape(t, handler)
+	// scrape는 값을 초기화하지 않으므로 delta counter, avg, summary도 같은 값을 노출한다.
+	if second := scrape(t, handler); second != first {
+		t.Fatalf("second scrape differs\nfirst:\n%s\nsecond:\n%s", first, second)
+	}
+
+	for _, want := range []string{
+		"# HELP net_if_up Link state.\n# TYPE net_if_up gauge\n",
+		`net_if_up{interface="eth\"0\\\n"} 1` + "\n",
+		"# HELP cpu_core_ticks Ticks \\\\ per\\ncore.\n# TYPE cpu_core_ticks untyped\ncpu_core_ticks 3\n",
+		"# TYPE disk_io_latency_mean gauge\ndisk_io_latency_mean 2\n",
+		"disk_io_latency_min 1\n",
+		"disk_io_latency_max 3\n",
+		"disk_io_latency_count 2\n",
+		"# TYPE http_req_duration summary\n",
+		"http_req_duration_sum 6\n",
+		"http_req_duration_count 3\n",
+		"# TYPE _1st_http_server_req_count gauge\n",
+		`_1st_http_server_req_count{status_code="200"} 5` + "\n",
+	} {
+		if !strings.Contains(first, want) {
+			t.Errorf("missing %q in\n%s", want, first)
+		}
+	}
+	if !strings.Contains(first, `http_req_duration{quantile="0.5"} `) {
+		t.Errorf("missing quantile sample in\n%s", first)
+	}
+}
+
+func TestSanitizeName(t *testing.T) {
+	tests := []struct {
+		name       string
+		allowColon bool
+		want       string
+	}{
+		{"cpu_usage", false, "cpu_usage"},
+		{"http-server.req", false, "http_server_req"},
+		{"9lives", false, "_9lives"},
+		{"a9", false, "a9"},
+		{"ns:metric", true, "ns:metric"},
+		{"ns:label", false, "ns_label"},
+		{"사용량", false, "___"},
+		{"", false, ""},
+	}
+	for _, tt := range tests {
+		if got := sanitizeName(tt.name, tt.allowColon); got != tt.want {
+			t.Errorf("sanitizeName(%q, %t) = %q, want %q", tt.name, tt.allowColon, got, tt.want)
+		}
+	}
+}
@@ -1,11 +1,28 @@
 package register
 
 import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"runtime"
+	"sort"
 	"sync"
 
 	"github.com/winey-dev/telemetry/metric"
 )
 
+// StringValueTagName은 Prometheus, OTLP sink에서 문자열 값을 기록하는 태그 이름이다.
+const StringValueTagName = "value"
+
+// ReservedTagNames는 sink에서 사용하기 때문에 Desc의 태그 이름으로 사용할 수 없다.
+var ReservedTagNames = []string{"item_name", "le", "quantile", StringValueTagName}
+
+// ReservedCategory.ReservedSubCategory(telemetry.agent)는 agent의 self metric이 사용하며 등록할 수 없다.
+const (
+	ReservedCategory    = "telemetry"
+	ReservedSubCategory = "agent"
+)
+
 type Agent interface {
 	Registerer
 	Start() error
@@ -14,21 +31,228 @@ type Agent interface {
 
 type Registry struct {
 	mtx        sync.RWMutex
-	collectors []metric.Collector
+	collectors []registeredCollector
+
+	collectorsByID  map[uint64]metric.Collector // collector ID -> collector
+	descIDs         map[uint64]struct{}
+	dimHashesByName map[string]uint64 // Category.SubCategory.ItemName -> tag names hash
+	helpsByName     map[string]string
+	refsByName      map[string]int
+
+	// seriesLimiter는 등록된 MetricVec 전체의 series 수를 제한한다. nil이면 제한하지 않는다.
+	seriesLimiter *metric.SeriesLimiter
+}
+
+// seriesLimited는 Registry의 series 수 제한을 적용할 수 있는 collector(MetricVec)가 구현한다.
+// collector는 여러 Registry에 등록될 수 있으므로 Registry는 자신의 limiter만 추가하고 제거한다.
+type seriesLimited interface {
+	AddSeriesLimiter(*metric.SeriesLimiter)
+	RemoveSeriesLimiter(*metric.SeriesLimiter)
+}
+
+// registeredCollector의 id는 Describe 결과가 없는 collector의 경우 0이다.
+// name은 오류 보고에 사용한다.
+type registeredCollector struct {
+	id        uint64
+	name      string
+	collector metric.Collector
+}
+
+// Closer를 구현한 collector는 Unregister 시점에 Close가 호출된다.
+type Closer interface {
+	Close()
+}
+
+// Ticker를 구현한 collector는 Registry.Tick이 호출될 때마다, 즉 agent의 주기마다 한 번 Tick이 호출된다.
+type Ticker interface {
+	Tick()
 }
 
 type Registerer interface {
 	Register(metric.Collector) error
 	Registers(...metric.Collector) error
+	Unregister(metric.Collector) bool
+}
+
+type Gatherer interface {
+	Gather(ctx context.Context) ([]metric.Metric, error)
 }
 
+// SetSeriesLimit은 등록된 MetricVec 전체의 series 수를 limit으로 제한한다. 0 이하면 제한하지 않는다.
+// 이미 등록된 Vec에도 적용되며 기존 series도 제한에 포함된다. 제한을 넘어선 태그 값은 각 Vec의 overflow series로 기록된다.
+func (r *Registry) SetSeriesLimit(limit int) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	var limiter *metric.SeriesLimiter
+	if limit > 0 {
+		limiter = metric.NewSeriesLimiter(limit)
+	}
+	for _, c := range r.collectors {
+		limited, ok := c.collector.(seriesLimited)
+		if !ok {
+			continue
+		}
+		if r.seriesLimiter != nil {
+			limited.RemoveSeriesLimiter(r.seriesLimiter)
+		}
+		if limiter != nil {
+			limited.AddSeriesLimiter(limiter)
+		}
+	}
+	r.seriesLimiter = limiter
+}
+
+// SeriesLimiter는 SetSeriesLimit으로 설정된 제한을 반환한다. 설정되지 않았으면 nil이다.
+func (r *Registry) SeriesLimiter() *metric.SeriesLimiter {
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+	return r.seriesLimiter
+}
+
+// Register는 collector의 Describe 결과를 검증한 뒤 등록한다.
+//   - 같은 Desc 집합을 가진 collector가 이미 등록되어 있으면 AlreadyRegisteredError
+//   - 이름이 같지만 태그 이름이나 설명이 다른 Desc, 예약된 태그 이름을 사용하는 Desc는 DescError
 func (r *Registry) Register(collector metric.Collector) error {
+	descs := describe(collector)
+
 	r.mtx.Lock()
 	defer r.mtx.Unlock()
-	r.collectors = append(r.collectors, collector)
+
+	if r.collectorsByID == nil {
+		r.collectorsByID = make(map[uint64]metric.Collector)
+		r.descIDs = make(map[uint64]struct{})
+		r.dimHashesByName = make(map[string]uint64)
+		r.helpsByName = make(map[string]string)
+		r.refsByName = make(map[string]int)
+	}
+
+	var (
+		collectorID    uint64
+		newDescIDs     = make(map[uint64]struct{}, len(descs))
+		newDimHashes   = make(map[string]uint64, len(descs))
+		duplicateDescs []*metric.Desc
+	)
+	for _, desc := range descs {
+		if err := validateDesc(desc); err != nil {
+			return err
+		}
+
+		descID := descID(desc)
+		if _, ok := newDescIDs[descID]; ok {
+			return &DescError{Desc: desc, Err: ErrDuplicateDesc}
+		}
+		newDescIDs[descID] = struct{}{}
+		if _, ok := r.descIDs[descID]; ok {
+			duplicateDescs = append(duplicateDescs, desc)
+		}
+		collectorID ^= descID
+
+		name := desc.String()
+		dimHash := dimHash(desc)
+		if existing, ok := r.dimHashesByName[name]; ok {
+			if existing != dimHash {
+				return &DescError{Desc: desc, Err: ErrInconsistentDesc}
+			}
+			if r.helpsByName[name] != desc.Description {
+				return &DescError{Desc: desc, Err: ErrInconsistentHelp}
+			}
+		} else if existing, ok := newDimHashes[name]; ok && existing != dimHash {
+			return &DescError{Desc: desc, Err: ErrInconsistentDesc}
+		}
+		newDimHashes[name] = dimHash
+	}
+
+	if len(descs) > 0 {
+		if existing, ok := r.collectorsByID[collectorID]; ok {
+			return AlreadyRegisteredError{ExistingCollector: existing, NewCollector: collector}
+		}
+		if len(duplicateDescs) > 0 {
+			return &DescError{Desc: duplicateDescs[0], Err: ErrDuplicateDesc}
+		}
+	}
+
+	for id := range newDescIDs {
+		r.descIDs[id] = struct{}{}
+	}
+	for _, desc := range descs {
+		r.dimHashesByName[desc.String()] = newDimHashes[desc.String()]
+		r.helpsByName[desc.String()] = desc.Description
+		r.refsByName[desc.String()]++
+	}
+	if len(descs) > 0 {
+		r.collectorsByID[collectorID] = collector
+	}
+	r.collectors = append(r.collectors, registeredCollector{id: collectorID, name: collectorName(collector, descs), collector: collector})
+	if limited, ok := collector.(seriesLimited); ok && r.seriesLimiter != nil {
+		limited.AddSeriesLimiter(r.seriesLimiter)
+	}
 	return nil
 }
 
+// Unregister는 collector와 같은 Desc 집합으로 등록된 collector를 제거한다.
+// 진행 중인 Gather는 제거 이전의 collector 목록으로 완료된다.
+// 제거된 collector가 Closer를 구현하면 Close를 호출한다.
+func (r *Registry) Unregister(collector metric.Collector) bool {
+	descs := describe(collector)
+	if len(descs) == 0 {
+		return false
+	}
+	var collectorID uint64
+	for _, desc := range descs {
+		collectorID ^= descID(desc)
+	}
+
+	r.mtx.Lock()
+	existing, ok := r.collectorsByID[collectorID]
+	if !ok {
+		r.mtx.Unlock()
+		return false
+	}
+	delete(r.collectorsByID, collectorID)
+	for _, desc := range descs {
+		delete(r.descIDs, descID(desc))
+		name := desc.String()
+		if r.refsByName[name]--; r.refsByName[name] <= 0 {
+			delete(r.refsByName, name)
+			delete(r.dimHashesByName, name)
+			delete(r.helpsByName, name)
+		}
+	}
+	// Gather가 이전 목록을 참조하고 있을 수 있으므로 새 slice를 생성한다.
+	collectors := make([]registeredCollector, 0, len(r.collectors))
+	for _, c := range r.collectors {
+		if c.id != collectorID {
+			collectors = append(collectors, c)
+		}
+	}
+	r.collectors = collectors
+	limiter := r.seriesLimiter
+	r.mtx.Unlock()
+
+	// 다른 Registry의 제한은 유지하고 이 Registry의 limiter만 제거한다.
+	if limited, ok := existing.(seriesLimited); ok && limiter != nil {
+		limited.RemoveSeriesLimiter(limiter)
+	}
+	if closer, ok := existing.(Closer); ok {
+		closer.Close()
+	}
+	return true
+}
+
+// Tick은 등록된 collector 중 Ticker를 구현한 collector의 Tick을 호출한다.
+// agent는 주기마다, Prometheus handler는 scrape마다 한 번 호출한다.
+func (r *Registry) Tick() {
+	r.mtx.RLock()
+	collectors := r.collectors
+	r.mtx.RUnlock()
+
+	for _, c := range collectors {
+		if ticker, ok := c.collector.(Ticker); ok {
+			ticker.Tick()
+		}
+	}
+}
+
 func (r *Registry) Registers(collectors ...metric.Collector) error {
 	for _, collector := range collectors {
 		if err := r.Register(collector); err != nil {
@@ -38,20 +262,189 @@ func (r *Registry) Registers(collectors ...metric.Collector) error {
 	return nil
 }
 
-func (r *Registry) Gather() ([]metric.Metric, error) {
+// Gather는 등록된 collector를 worker pool에서 병렬로 수집한다.
+// ctx가 만료되면 그 시점까지 수집된 결과와 함께 완료되지 않은 collector를 CollectorError로 반환한다.
+// 결과는 collector 등록 순서를 유지한다.
+func (r *Registry) Gather(ctx context.Context) ([]metric.Metric, error) {
+	r.mtx.RLock()
+	collectors := r.collectors
+	r.mtx.RUnlock()
+
+	if len(collectors) == 0 {
+		return nil, nil
+	}
+
+	workers := runtime.GOMAXPROCS(0) * gatherWorkersPerCPU
+	if workers > len(collectors) {
+		workers = len(collectors)
+	}
+
+	jobs := make(chan int)
+	results := make(chan collectResult, len(collectors))
+	for i := 0; i < workers; i++ {
+		go func() {
+			for idx := range jobs {
+				results <- collect(idx, collectors[idx].collector)
+			}
+		}()
+	}
+	go func() {
+		defer close(jobs)
+		for idx := range collectors {
+			select {
+			case jobs <- idx:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	gathered := make([][]metric.Metric, len(collectors))
+	done := make([]bool, len(collectors))
+	var errs MultiError
+wait:
+	for received := 0; received < len(collectors); received++ {
+		select {
+		case res := <-results:
+			done[res.idx] = true
+			gathered[res.idx] = res.metrics
+			if res.err != nil {
+				errs = append(errs, &CollectorError{Name: collectors[res.idx].name, Err: res.err})
+			}
+		case <-ctx.Done():
+			break wait
+		}
+	}
+	for idx, ok := range done {
+		if !ok {
+			errs = append(errs, &CollectorError{Name: collectors[idx].name, Err: ctx.Err()})
+		}
+	}
+
 	var metrics []metric.Metric
+	for _, m := range gathered {
+		metrics = append(metrics, m...)
+	}
+	if len(errs) > 0 {
+		return metrics, errs
+	}
+	return metrics, nil
+}
+
+const gatherWorkersPerCPU = 4
+
+type collectResult struct {
+	idx     int
+	metrics []metric.Metric
+	err     error
+}
+
+// collect는 collector 하나를 수집한다. Collect 중 발생한 panic은 오류로 변환된다.
+func collect(idx int, collector metric.Collector) (res collectResult) {
+	res.idx = idx
 	metricChan := make(chan metric.Metric)
+	panicChan := make(chan interface{}, 1)
+	go func() {
+		defer close(metricChan)
+		defer func() {
+			if p := recover(); p != nil {
+				panicChan <- p
+			}
+		}()
+		collector.Collect(metricChan)
+	}()
+	for m := range metricChan {
+		res.metrics = append(res.metrics, m)
+	}
+	select {
+	case p := <-panicChan:
+		res.err = fmt.Errorf("panic during collect: %v", p)
+	default:
+	}
+	return res
+}
 
+func collectorName(collector metric.Collector, descs []*metric.Desc) string {
+	if len(descs) > 0 {
+		return descs[0].String()
+	}
+	return fmt.Sprintf("%T", collector)
+}
+
+func describe(collector metric.Collector) []*metric.Desc {
+	descChan := make(chan *metric.Desc)
 	go func() {
-		for _, c := range r.collectors {
-			c.Collect(metricChan)
+		collector.Describe(descChan)
+		close(descChan)
+	}()
+	var descs []*metric.Desc
+	for desc := range descChan {
+		descs = append(descs, desc)
+	}
+	return descs
+}
 
+func validateDesc(desc *metric.Desc) error {
+	if desc == nil {
+		return &DescError{Desc: &metric.Desc{}, Err: ErrInvalidTagName}
+	}
+	if desc.Category == ReservedCategory && desc.SubCategory == ReservedSubCategory {
+		return &DescError{Desc: desc, Err: ErrReservedCategory}
+	}
+	if !desc.ConstraintTags.IsValid() {
+		return &DescError{Desc: desc, Err: ErrInvalidTagValues}
+	}
+	seen := make(map[string]struct{})
+	for _, tagName := range desc.TagNamesWithConstraint() {
+		if tagName == "" {
+			return &DescError{Desc: desc, Err: ErrInvalidTagName}
 		}
-		close(metricChan)
-	}()
+		for _, reserved := range ReservedTagNames {
+			if tagName == reserved {
+				return &DescError{Desc: desc, Err: ErrReservedTagName}
+			}
+		}
+		if _, ok := seen[tagName]; ok {
+			return &DescError{Desc: desc, Err: ErrDuplicateTagName}
+		}
+		seen[tagName] = struct{}{}
+	}
+	return nil
+}
+
+const separatorByte = 255
 
-	for metric := range metricChan {
-		metrics = append(metrics, metric)
+// descID는 이름과 ConstraintTags 값으로 Desc를 식별한다.
+func descID(desc *metric.Desc) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(desc.String()))
+	h.Write([]byte{separatorByte})
+
+	indexes := make([]int, len(desc.ConstraintTags.TagNames))
+	for i := range indexes {
+		indexes[i] = i
 	}
-	return metrics, nil
+	sort.Slice(indexes, func(i, j int) bool {
+		return desc.ConstraintTags.TagNames[indexes[i]] < desc.ConstraintTags.TagNames[indexes[j]]
+	})
+	for _, i := range indexes {
+		h.Write([]byte(desc.ConstraintTags.TagNames[i]))
+		h.Write([]byte{separatorByte})
+		h.Write([]byte(desc.ConstraintTags.TagValues[i]))
+		h.Write([]byte{separatorByte})
+	}
+	return h.Sum64()
+}
+
+// dimHash는 정렬된 태그 이름 목록으로 Desc의 차원을 식별한다.
+func dimHash(desc *metric.Desc) uint64 {
+	tagNames := append([]string(nil), desc.TagNamesWithConstraint()...)
+	sort.Strings(tagNames)
+
+	h := fnv.New64a()
+	for _, tagName := range tagNames {
+		h.Write([]byte(tagName))
+		h.Write([]byte{separatorByte})
+	}
+	return h.Sum64()
 }
@@ -0,0 +1,313 @@
+package register
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/winey-dev/telemetry/metric"
+)
+
+func newLimitedVec() *metric.GaugeVec {
+	return metric.NewGaugeVec(metric.GaugeOpts{Category: "test", SubCategory: "limit", ItemName: "usage"}, "host")
+}
+
+// seriesCount는 Gather로 수집된 series 수를 반환한다.
+func seriesCount(t *testing.T, r *Registry) int {
+	t.Helper()
+	metrics, err := r.Gather(context.Background())
+	if err != nil {
+		t.Fatalf("Gather: %v", err)
+	}
+	return len(metrics)
+}
+
+func TestUnregisterKeepsOtherRegistryLimit(t *testing.T) {
+	var a, b Registry
+	a.SetSeriesLimit(2)
+	b.SetSeriesLimit(10)
+
+	vec := newLimitedVec()
+	if err := a.Register(vec); err != nil {
+		t.Fatalf("register a: %v", err)
+	}
+	if err := b.Register(vec); err != nil {
+		t.Fatalf("register b: %v", err)
+	}
+	if !b.Unregister(vec) {
+		t.Fatal("unregister b failed")
+	}
+
+	for _, host := range []string{"h1", "h2", "h3", "h4"} {
+		vec.WithTagValues(host).Set(1)
+	}
+	// h1, h2와 overflow series
+	if n := seriesCount(t, &a); n != 3 {
+		t.Fatalf("series = %d, want 3", n)
+	}
+	if got := a.SeriesLimiter().Rejected(); got != 2 {
+		t.Fatalf("rejected = %d, want 2", got)
+	}
+	if got := b.SeriesLimiter().Series(); got != 0 {
+		t.Fatalf("unregistered limiter series = %d, want 0", got)
+	}
+}
+
+func TestSetSeriesLimitAppliesToRegisteredVec(t *testing.T) {
+	var r Registry
+	vec := newLimitedVec()
+	if err := r.Register(vec); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	vec.WithTagValues("h1").Set(1)
+	vec.WithTagValues("h2").Set(1)
+
+	r.SetSeriesLimit(3)
+	if got := r.SeriesLimiter().Series(); got != 2 {
+		t.Fatalf("series after SetSeriesLimit = %d, want 2", got)
+	}
+	vec.WithTagValues("h3").Set(1)
+	vec.WithTagValues("h4").Set(1)
+	if n := seriesCount(t, &r); n != 4 {
+		t.Fatalf("series = %d, want 4 (3 + overflow)", n)
+	}
+
+	// 제한을 바꾸면 이전 limiter에서 획득한 series는 반환된다.
+	old := r.SeriesLimiter()
+	r.SetSeriesLimit(0)
+	if got := old.Series(); got != 0 {
+		t.Fatalf("previous limiter series = %d, want 0", got)
+	}
+	vec.WithTagValues("h5").Set(1)
+	if n := seriesCount(t, &r); n != 5 {
+		t.Fatalf("series without limit = %d, want 5", n)
+	}
+}
+
+func TestTTLCountsTicksAndWrites(t *testing.T) {
+	var r Registry
+	vec := newLimitedVec()
+	var evicted []string
+	vec.SetTTL(2, func(tagValues []string, _ metric.Metric) {
+		evicted = append(evicted, tagValues[0])
+	})
+	if err := r.Register(vec); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	// 보관한 Metric에 값을 기록하는 series는 WithTagValues를 다시 호출하지 않아도 유지된다.
+	written := vec.WithTagValues("written")
+	vec.WithTagValues("idle").Set(1)
+	for i := 0; i < 3; i++ {
+		written.Set(float64(i))
+		// 주기 안에서 여러 번 수집되어도 주기는 Tick마다 한 번만 진행된다.
+		for j := 0; j < 5; j++ {
+			seriesCount(t, &r)
+		}
+		r.Tick()
+	}
+
+	if len(evicted) != 1 || evicted[0] != "idle" {
+		t.Fatalf("evicted = %v, want [idle]", evicted)
+	}
+	if n := seriesCount(t, &r); n != 1 {
+		t.Fatalf("series = %d, want 1", n)
+	}
+}
+
+func TestRegisterAlreadyRegistered(t *testing.T) {
+	var r Registry
+	vec := newLimitedVec()
+	if err := r.Register(vec); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	for name, collector := range map[string]metric.Collector{
+		"same collector": vec,
+		"same desc":      newLimitedVec(),
+	} {
+		err := r.Register(collector)
+		var are AlreadyRegisteredError
+		if !errors.As(err, &are) {
+			t.Fatalf("%s: err = %v, want AlreadyRegisteredError", name, err)
+		}
+		if are.ExistingCollector != vec || are.NewCollector != collector {
+			t.Fatalf("%s: existing %p new %p, want %p %p", name, are.ExistingCollector, are.NewCollector, vec, collector)
+		}
+	}
+	if n := len(r.collectors); n != 1 {
+		t.Fatalf("registered collectors = %d, want 1", n)
+	}
+}
+
+func TestRegisterConflictingDesc(t *testing.T) {
+	opts := metric.GaugeOpts{Category: "test", SubCategory: "limit", ItemName: "usage"}
+	tests := []struct {
+		name      string
+		collector metric.Collector
+		want      error
+	}{
+		{"different tag names", metric.NewGaugeVec(opts, "interface"), ErrInconsistentDesc},
+		{"additional tag name", metric.NewGaugeVec(opts, "host", "interface"), ErrInconsistentDesc},
+		{"different description", metric.NewGaugeVec(metric.GaugeOpts{
+			Category: "test", SubCategory: "limit", ItemName: "usage", Description: "other",
+		}, "host"), ErrInconsistentHelp},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var r Registry
+			if err := r.Register(newLimitedVec()); err != nil {
+				t.Fatalf("register: %v", err)
+			}
+			err := r.Register(tt.collector)
+			var descErr *DescError
+			if !errors.As(err, &descErr) || !errors.Is(err, tt.want) {
+				t.Fatalf("err = %v, want DescError(%v)", err, tt.want)
+			}
+			if descErr.Desc.String() != "test.limit.usage" {
+				t.Fatalf("desc = %s", descErr.Desc)
+			}
+		})
+	}
+}
+
+func TestRegisterConflictingKind(t *testing.T) {
+	var r Registry
+	opts := metric.Opts{Category: "test", SubCategory: "limit", ItemName: "usage"}
+	counter := metric.NewCounterVec(metric.CounterOpts(opts), "host")
+	if err := r.Register(counter); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	// 이름과 태그 이름이 같으면 종류가 달라도 같은 series로 기록되므로 등록할 수 없다.
+	err := r.Register(metric.NewGaugeVec(metric.GaugeOpts(opts), "host"))
+	var are AlreadyRegisteredError
+	if !errors.As(err, &are) || are.ExistingCollector != counter {
+		t.Fatalf("err = %v, want AlreadyRegisteredError with the counter", err)
+	}
+}
+
+func TestRegisterRejectsReservedNames(t *testing.T) {
+	tests := []struct {
+		name      string
+		collector metric.Collector
+		want      error
+	}{
+		{"item_name", metric.NewGaugeVec(metric.GaugeOpts{Category: "c", SubCategory: "s", ItemName: "i"}, "item_name"), ErrReservedTagName},
+		{"value", metric.NewGaugeVec(metric.GaugeOpts{Category: "c", SubCategory: "s", ItemName: "i"}, "host", StringValueTagName), ErrReservedTagName},
+		{"constraint le", metric.NewGauge(metric.GaugeOpts{Category: "c", SubCategory: "s", ItemName: "i",
+			ConstraintTags: metric.ConstraintTags{TagNames: []string{"le"}, TagValues: []string{"1"}}}), ErrReservedTagName},
+		{"duplicate", metric.NewGaugeVec(metric.GaugeOpts{Category: "c", SubCategory: "s", ItemName: "i"}, "host", "host"), ErrDuplicateTagName},
+		{"self category", metric.NewGauge(metric.GaugeOpts{Category: ReservedCategory, SubCategory: ReservedSubCategory, ItemName: "i"}), ErrReservedCategory},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var r Registry
+			err := r.Register(tt.collector)
+			var descErr *DescError
+			if !errors.As(err, &descErr) || !errors.Is(err, tt.want) {
+				t.Fatalf("err = %v, want DescError(%v)", err, tt.want)
+			}
+			if n := seriesCount(t, &r); n != 0 {
+				t.Fatalf("rejected collector gathered %d series", n)
+			}
+		})
+	}
+}
+
+// closingVec은 Close 호출 횟수를 기록한다.
+type closingVec struct {
+	*metric.GaugeVec
+	closed int
+}
+
+func (c *closingVec) Close() { c.closed++ }
+
+func TestUnregister(t *testing.T) {
+	var r Registry
+	if r.Unregister(newLimitedVec()) {
+		t.Fatal("unregister on an empty registry returned true")
+	}
+
+	vec := &closingVec{GaugeVec: newLimitedVec()}
+	other := metric.NewGaugeVec(metric.GaugeOpts{Category: "test", SubCategory: "limit", ItemName: "other"}, "host")
+	if err := r.Registers(vec, other); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	vec.WithTagValues("h1").Set(1)
+	vec.WithTagValues("h2").Set(1)
+	other.WithTagValues("h1").Set(1)
+	if n := seriesCount(t, &r); n != 3 {
+		t.Fatalf("series = %d, want 3", n)
+	}
+
+	unknown := metric.NewGaugeVec(metric.GaugeOpts{Category: "test", SubCategory: "limit", ItemName: "unknown"}, "host")
+	if r.Unregister(unknown) {
+		t.Fatal("unregister of an unknown collector returned true")
+	}
+	if !r.Unregister(vec) {
+		t.Fatal("unregister failed")
+	}
+	if r.Unregister(vec) {
+		t.Fatal("second unregister returned true")
+	}
+	if vec.closed != 1 {
+		t.Fatalf("Close called %d times, want 1", vec.closed)
+	}
+
+	metrics, err := r.Gather(context.Background())
+	if err != nil {
+		t.Fatalf("Gather: %v", err)
+	}
+	if len(metrics) != 1 || metrics[0].Desc().ItemName != "other" {
+		t.Fatalf("gathered %d metrics after unregister, want only other", len(metrics))
+	}
+
+	// 제거된 이름은 다른 태그 이름으로 다시 등록할 수 있다.
+	if err := r.Register(metric.NewGaugeVec(metric.GaugeOpts{Category: "test", SubCategory: "limit", ItemName: "usage"}, "interface")); err != nil {
+		t.Fatalf("register after unregister: %v", err)
+	}
+}
+
+// blockingCollector는 release가 닫힐 때까지 Collect를 반환하지 않는다.
+type blockingCollector struct {
+	desc    *metric.Desc
+	release chan struct{}
+}
+
+func (b *blockingCollector) Describe(ch chan<- *metric.Desc) { ch <- b.desc }
+
+func (b *blockingCollector) Collect(ch chan<- metric.Metric) { <-b.release }
+
+func TestGatherReturnsPartialResults(t *testing.T) {
+	var r Registry
+	slow := &blockingCollector{
+		desc:    metric.NewDesc("test", "gather", "slow", "", metric.ConstraintTags{}),
+		release: make(chan struct{}),
+	}
+	defer close(slow.release)
+	healthy := newLimitedVec()
+	healthy.WithTagValues("h1").Set(1)
+	healthy.WithTagValues("h2").Set(2)
+	if err := r.Registers(slow, healthy); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	metrics, err := r.Gather(ctx)
+	if len(metrics) != 2 {
+		t.Fatalf("gathered %d metrics, want 2 from the healthy collector", len(metrics))
+	}
+	var errs MultiError
+	if !errors.As(err, &errs) || len(errs) != 1 {
+		t.Fatalf("err = %v, want MultiError with one error", err)
+	}
+	var collectorErr *CollectorError
+	if !errors.As(errs[0], &collectorErr) || collectorErr.Name != "test.gather.slow" {
+		t.Fatalf("err = %v, want CollectorError for test.gather.slow", errs[0])
+	}
+	if !errors.Is(collectorErr, context.DeadlineExceeded) {
+		t.Fatalf("collector err = %v, want DeadlineExceeded", collectorErr.Err)
+	}
+}